@@ -3,25 +3,82 @@
 package mapz
 
 import (
+	"context"
+	"encoding/json"
 	"sync"
 	"sync/atomic"
+
+	"golang.org/x/exp/maps"
 )
 
 // AppendMap is a concurrency-safe append-only map. Its interface resembles a subset of sync.Map.
 // Loads on existing values only need an atomic read. Loads of non-existent and recently stored values pick up a mutex. Stores pick up a mutex.
+// Values can't be changed once stored, but the map can be cleared and (expensively) keys can be deleted.
+//
+// Stores go into a mutex protected map, which is promoted to the lock-free map when the PromotionPolicy says so. The next store after a promotion has to copy the map.
+// Use Stats to see how your workload behaves.
+//
+// AppendMap implements json.Marshaler with a pointer receiver, because it contains a mutex. encoding/json only uses it if the AppendMap is addressable, so marshal a pointer to a struct containing an AppendMap. Marshaling such a struct by value encodes the AppendMap as {}.
+//
 // The zero value is valid.
 type AppendMap[K comparable, V any] struct {
+	// PromotionPolicy decides when recently stored values are promoted to the lock-free map. If nil, PromoteAtRatio(1) is used.
+	// It should be set before the map is first used.
+	PromotionPolicy PromotionPolicy
+	// CountFastHits enables counting AppendMapStats.FastHits. This is disabled by default because it adds an atomic write to the otherwise read-only fast path.
+	// It should be set before the map is first used.
+	CountFastHits bool
+
 	fast     atomic.Pointer[map[K]V]
 	l        sync.Mutex
 	truth    map[K]V
 	slowHits int
+	calls    map[K]*computeCall[V]
+
+	fastHits atomic.Uint64
+	misses   atomic.Uint64
+	stats    AppendMapStats
+}
+
+// PromotionPolicy decides whether an AppendMap should promote its recently stored values to the lock-free map.
+// It is called (with the lock held) whenever a lookup had to pick up the mutex to find its value, with the number of such lookups since the last promotion and the number of entries in the map.
+//
+// Promoting too eagerly causes a lot of copying if stores and loads are interleaved, as the first store after each promotion copies the entire map.
+// Promoting too lazily makes loads of recently stored values pick up the mutex for longer.
+type PromotionPolicy func(slowHits, size int) bool
+
+// PromoteAtRatio returns a PromotionPolicy that promotes once the number of lookups that needed the mutex reaches ratio times the size of the map.
+// This amortizes the cost of copying the map over the lookups. Higher ratios are better for write-bursty workloads.
+func PromoteAtRatio(ratio float64) PromotionPolicy {
+	return func(slowHits, size int) bool {
+		return float64(slowHits) >= ratio*float64(size)
+	}
 }
 
+// AppendMapStats contains counters about an AppendMap. Use them to tune the PromotionPolicy.
+type AppendMapStats struct {
+	// FastHits is the number of lookups that found their value in the lock-free map. It is only counted if CountFastHits is set.
+	FastHits uint64
+	// SlowHits is the number of lookups that needed to pick up the mutex to find their value.
+	SlowHits uint64
+	// Misses is the number of lookups that didn't find a value.
+	Misses uint64
+	// Promotions is the number of times the recently stored values were promoted to the lock-free map.
+	Promotions uint64
+	// Copies is the number of times the lock-free map had to be copied to accept new values.
+	Copies uint64
+	// Unpromoted is the number of entries in the mutex protected map that hasn't been promoted yet. It is 0 if all entries have been promoted.
+	Unpromoted int
+}
+
+var _ AppendOnlyMap[string, int] = &AppendMap[string, int]{}
+
 // Load returns the value stored in the map for a key. The ok result indicates whether value was found in the map.
 func (m *AppendMap[K, V]) Load(key K) (V, bool) {
 	fast := m.fast.Load()
 	if fast != nil {
 		if v, ok := (*fast)[key]; ok {
+			m.fastHit()
 			return v, true
 		}
 	}
@@ -30,21 +87,35 @@ func (m *AppendMap[K, V]) Load(key K) (V, bool) {
 		m.l.Unlock()
 		if f := m.fast.Load(); f != nil && f != fast {
 			if v, ok := (*f)[key]; ok {
+				m.fastHit()
 				return v, true
 			}
 		}
 	} else if v, ok := m.truth[key]; ok {
-		m.slowHits++
-		m.considerPromotion()
+		m.slowHit()
 		m.l.Unlock()
 		return v, true
 	} else {
 		m.l.Unlock()
 	}
+	m.misses.Add(1)
 	var zero V
 	return zero, false
 }
 
+func (m *AppendMap[K, V]) fastHit() {
+	if m.CountFastHits {
+		m.fastHits.Add(1)
+	}
+}
+
+// slowHit records a lookup that found its value in truth. m.l must be held.
+func (m *AppendMap[K, V]) slowHit() {
+	m.stats.SlowHits++
+	m.slowHits++
+	m.considerPromotion()
+}
+
 // LoadOrZero returns the value stored in the map for a key, or zero if no value is present. This is the same as Load() but ignoring the second result.
 func (m *AppendMap[K, V]) LoadOrZero(key K) V {
 	v, _ := m.Load(key)
@@ -55,43 +126,284 @@ func (m *AppendMap[K, V]) LoadOrZero(key K) V {
 func (m *AppendMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
 	if f := m.fast.Load(); f != nil {
 		if v, ok := (*f)[key]; ok {
+			m.fastHit()
 			return v, true
 		}
 	}
 	m.l.Lock()
 	defer m.l.Unlock()
+	return m.loadOrStoreLocked(key, value)
+}
+
+// loadOrStoreLocked is LoadOrStore without the lock-free fast path. m.l must be held.
+func (m *AppendMap[K, V]) loadOrStoreLocked(key K, value V) (actual V, loaded bool) {
 	if m.truth == nil {
 		if f := m.fast.Load(); f != nil {
 			if v, ok := (*f)[key]; ok {
+				m.stats.SlowHits++
 				return v, true
 			}
-			m.truth = make(map[K]V, len(*f))
-			for k, v := range *f {
-				m.truth[k] = v
-			}
-		} else {
-			m.truth = map[K]V{}
 		}
+		m.copyFastLocked()
 	} else if v, ok := m.truth[key]; ok {
-		m.slowHits++
-		m.considerPromotion()
+		m.slowHit()
 		return v, true
 	}
+	m.misses.Add(1)
 	m.truth[key] = value
 	return value, false
 }
 
+// copyFastLocked initializes truth with a copy of fast, so it can be modified. m.l must be held.
+func (m *AppendMap[K, V]) copyFastLocked() {
+	f := m.fast.Load()
+	if f == nil {
+		m.truth = map[K]V{}
+		return
+	}
+	m.stats.Copies++
+	m.truth = make(map[K]V, len(*f))
+	for k, v := range *f {
+		m.truth[k] = v
+	}
+}
+
+// LoadOrCompute returns the existing value for the key if present. Otherwise, it calls fn and stores and returns its result.
+// fn runs at most once per key at a time: concurrent callers for the same key wait for the running fn and get its result.
+// If fn returns an error, nothing is stored and the error is returned to all callers waiting for it. The next call will try again.
+// If fn panics, the panic is propagated to the caller that ran it and waiting callers get a *ComputePanicError.
+//
+// fn is called without holding the lock, so it may call any method on m.
+func (m *AppendMap[K, V]) LoadOrCompute(key K, fn func() (V, error)) (V, error) {
+	if f := m.fast.Load(); f != nil {
+		if v, ok := (*f)[key]; ok {
+			m.fastHit()
+			return v, nil
+		}
+	}
+	return loadOrCompute(&m.l, &m.calls, key, m.loadLocked, m.computed, fn)
+}
+
+// LoadOrComputeContext is like LoadOrCompute, but callers waiting for the computation return ctx.Err() when their ctx is cancelled.
+// fn runs in its own goroutine, so it keeps going for the remaining callers when the caller that started it gives up.
+// The context passed to fn carries the values of the ctx of the caller that started it, and is only cancelled once all callers waiting for it have given up.
+// If fn panics, the caller that started it panics with a *ComputePanicError holding the panic value and stack trace, and the other callers waiting for it get that error.
+func (m *AppendMap[K, V]) LoadOrComputeContext(ctx context.Context, key K, fn func(ctx context.Context) (V, error)) (V, error) {
+	if f := m.fast.Load(); f != nil {
+		if v, ok := (*f)[key]; ok {
+			m.fastHit()
+			return v, nil
+		}
+	}
+	return loadOrComputeContext(ctx, &m.l, &m.calls, key, m.loadLocked, m.computed, fn)
+}
+
+// peekLocked looks up key in whichever of truth and fast is authoritative without updating any counters. m.l must be held.
+func (m *AppendMap[K, V]) peekLocked(key K) (V, bool) {
+	if m.truth != nil {
+		v, ok := m.truth[key]
+		return v, ok
+	}
+	if f := m.fast.Load(); f != nil {
+		v, ok := (*f)[key]
+		return v, ok
+	}
+	var zero V
+	return zero, false
+}
+
+// storeLocked stores a value for a key that isn't present yet. m.l must be held.
+func (m *AppendMap[K, V]) storeLocked(key K, value V) {
+	if m.truth == nil {
+		m.copyFastLocked()
+	}
+	m.truth[key] = value
+}
+
+// loadLocked looks up key in whichever of truth and fast is authoritative. m.l must be held.
+func (m *AppendMap[K, V]) loadLocked(key K) (V, bool) {
+	if m.truth != nil {
+		if v, ok := m.truth[key]; ok {
+			m.slowHit()
+			return v, true
+		}
+	} else if f := m.fast.Load(); f != nil {
+		if v, ok := (*f)[key]; ok {
+			m.stats.SlowHits++
+			return v, true
+		}
+	}
+	m.misses.Add(1)
+	var zero V
+	return zero, false
+}
+
+// computed stores the result of LoadOrCompute unless a value was stored in the meantime. m.l must be held.
+func (m *AppendMap[K, V]) computed(key K, value V) V {
+	if v, ok := m.peekLocked(key); ok {
+		return v
+	}
+	m.storeLocked(key, value)
+	return value
+}
+
+// Clear removes all entries from the map, atomically starting a new generation.
+//
+// Loads and Ranges concurrent with Clear see the map either entirely before or entirely after the Clear. Maps returned by Snapshot before the Clear are unaffected.
+// LoadOrCompute calls that are in-flight during the Clear return their result to their callers, but don't store it in the new generation.
+func (m *AppendMap[K, V]) Clear() {
+	m.l.Lock()
+	defer m.l.Unlock()
+	m.clearLocked()
+}
+
+func (m *AppendMap[K, V]) clearLocked() {
+	m.fast.Store(nil)
+	m.truth = nil
+	m.slowHits = 0
+	m.calls = nil
+}
+
+// Delete deletes the value for a key.
+//
+// Delete is supported, but expensive: it might need to copy the entire map to keep serving lock-free Loads. Maps returned by Snapshot before the Delete are unaffected.
+func (m *AppendMap[K, V]) Delete(key K) {
+	m.l.Lock()
+	defer m.l.Unlock()
+	if m.truth == nil {
+		f := m.fast.Load()
+		if f == nil {
+			return
+		}
+		if _, ok := (*f)[key]; !ok {
+			return
+		}
+		m.copyFastLocked()
+	} else if _, ok := m.truth[key]; !ok {
+		return
+	}
+	delete(m.truth, key)
+	m.promote()
+}
+
+// StoreAll stores all entries in the map, except for keys that are already present, as values in an AppendMap never change.
+// The lock is taken only once, the map is copied at most once and the new entries are promoted to the lock-free map right away.
+func (m *AppendMap[K, V]) StoreAll(entries map[K]V) {
+	m.l.Lock()
+	defer m.l.Unlock()
+	stored := false
+	for k, v := range entries {
+		if _, ok := m.peekLocked(k); !ok {
+			m.storeLocked(k, v)
+			stored = true
+		}
+	}
+	if stored {
+		m.promote()
+	}
+}
+
+// LoadMany returns the values stored in the map for the given keys. Keys that aren't present are omitted from the result.
+// Keys that are in the lock-free map are looked up without locking, the lock is taken at most once for the others.
+func (m *AppendMap[K, V]) LoadMany(keys ...K) map[K]V {
+	ret := make(map[K]V, len(keys))
+	var missing []K
+	if f := m.fast.Load(); f != nil {
+		for _, k := range keys {
+			if v, ok := (*f)[k]; ok {
+				m.fastHit()
+				ret[k] = v
+			} else {
+				missing = append(missing, k)
+			}
+		}
+	} else {
+		missing = keys
+	}
+	if len(missing) == 0 {
+		return ret
+	}
+	m.l.Lock()
+	defer m.l.Unlock()
+	for _, k := range missing {
+		if v, ok := m.loadLocked(k); ok {
+			ret[k] = v
+		}
+	}
+	return ret
+}
+
+// DeleteMany deletes the values for the given keys.
+// Like Delete it's expensive, but it copies the map at most once.
+func (m *AppendMap[K, V]) DeleteMany(keys ...K) {
+	m.l.Lock()
+	defer m.l.Unlock()
+	deleted := false
+	for _, k := range keys {
+		if m.truth == nil {
+			f := m.fast.Load()
+			if f == nil {
+				return
+			}
+			if _, ok := (*f)[k]; !ok {
+				continue
+			}
+			m.copyFastLocked()
+		} else if _, ok := m.truth[k]; !ok {
+			continue
+		}
+		delete(m.truth, k)
+		deleted = true
+	}
+	if deleted {
+		m.promote()
+	}
+}
+
+// LoadAndDeleteAll atomically clears the map and returns the entries it had. See Clear for the semantics.
+// If the entries were available to lock-free readers, the returned map is a copy, because concurrent Loads, Snapshots and Ranges might still be using the old one.
+func (m *AppendMap[K, V]) LoadAndDeleteAll() map[K]V {
+	m.l.Lock()
+	defer m.l.Unlock()
+	ret := m.truth
+	if ret == nil {
+		if f := m.fast.Load(); f != nil {
+			ret = maps.Clone(*f)
+		}
+	}
+	m.clearLocked()
+	return ret
+}
+
 func (m *AppendMap[K, V]) considerPromotion() {
-	if m.slowHits >= len(m.truth) {
+	policy := m.PromotionPolicy
+	if policy == nil {
+		policy = defaultPromotionPolicy
+	}
+	if policy(m.slowHits, len(m.truth)) {
 		m.promote()
 	}
 }
 
+var defaultPromotionPolicy = PromoteAtRatio(1)
+
 func (m *AppendMap[K, V]) promote() {
 	f := m.truth
 	m.fast.Store(&f)
 	m.truth = nil
 	m.slowHits = 0
+	m.stats.Promotions++
+}
+
+// Stats returns the counters of the map.
+func (m *AppendMap[K, V]) Stats() AppendMapStats {
+	m.l.Lock()
+	s := m.stats
+	s.Unpromoted = len(m.truth)
+	m.l.Unlock()
+	s.FastHits = m.fastHits.Load()
+	s.Misses = m.misses.Load()
+	return s
 }
 
 // Range calls f sequentially for each key and value present in the map. If f returns false, range stops the iteration.
@@ -135,3 +447,25 @@ func (m *AppendMap[K, V]) Snapshot() map[K]V {
 	}
 	return nil
 }
+
+// MarshalJSON encodes the map as a JSON object, with the same rules as encoding/json uses for a map[K]V. Keys are sorted, integer keys by their value.
+// The method has a pointer receiver, see AppendMap.
+func (m *AppendMap[K, V]) MarshalJSON() ([]byte, error) {
+	return marshalSortedJSON(m.Snapshot())
+}
+
+// UnmarshalJSON replaces the contents of the map with the given JSON object. Like Clear, it atomically starts a new generation.
+func (m *AppendMap[K, V]) UnmarshalJSON(data []byte) error {
+	var n map[K]V
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	m.l.Lock()
+	defer m.l.Unlock()
+	m.clearLocked()
+	if len(n) > 0 {
+		m.truth = n
+		m.promote()
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+//go:build go1.19
+
+package mapz
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+)
+
+func TestAppendMapClear(t *testing.T) {
+	var m AppendMap[string, int]
+	m.LoadOrStore("a", 1)
+	m.LoadOrStore("b", 2)
+	snap := m.Snapshot()
+	m.Clear()
+	if _, ok := m.Load("a"); ok {
+		t.Errorf("Load(a) found a value after Clear")
+	}
+	if got := m.Len(); got != 0 {
+		t.Errorf("Len() after Clear = %d; want 0", got)
+	}
+	if len(snap) != 2 {
+		t.Errorf("Clear modified an earlier snapshot: %v", snap)
+	}
+	if v, loaded := m.LoadOrStore("a", 3); loaded || v != 3 {
+		t.Errorf("LoadOrStore(a, 3) after Clear = %d, %v; want 3, false", v, loaded)
+	}
+	for i := 0; i < 3; i++ {
+		if v, ok := m.Load("a"); !ok || v != 3 {
+			t.Errorf("Load(a) = %d, %v; want 3, true", v, ok)
+		}
+	}
+	if m.fast.Load() == nil {
+		t.Errorf("the fast path wasn't restored after Clear")
+	}
+}
+
+func TestAppendMapDelete(t *testing.T) {
+	var m AppendMap[string, int]
+	m.Delete("a")
+	m.LoadOrStore("a", 1)
+	m.LoadOrStore("b", 2)
+	snap := m.Snapshot()
+	m.Delete("a")
+	if _, ok := m.Load("a"); ok {
+		t.Errorf("Load(a) found a deleted value")
+	}
+	if v, ok := m.Load("b"); !ok || v != 2 {
+		t.Errorf("Load(b) = %d, %v; want 2, true", v, ok)
+	}
+	if len(snap) != 2 {
+		t.Errorf("Delete modified an earlier snapshot: %v", snap)
+	}
+	m.LoadOrStore("c", 3)
+	m.Delete("c")
+	m.Delete("nonexistent")
+	if got := m.Len(); got != 1 {
+		t.Errorf("Len() = %d; want 1", got)
+	}
+	if v, loaded := m.LoadOrStore("a", 4); loaded || v != 4 {
+		t.Errorf("LoadOrStore(a, 4) after Delete = %d, %v; want 4, false", v, loaded)
+	}
+}
+
+func TestAppendMapClearDuringLoadOrCompute(t *testing.T) {
+	var m AppendMap[string, int]
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		v, err := m.LoadOrCompute("a", func() (int, error) {
+			close(started)
+			<-release
+			return 1, nil
+		})
+		if err != nil || v != 1 {
+			t.Errorf("LoadOrCompute = %d, %v; want 1, nil", v, err)
+		}
+	}()
+	<-started
+	m.Clear()
+	close(release)
+	<-done
+	if v, ok := m.Load("a"); ok {
+		t.Errorf("computation from before Clear was stored: %d", v)
+	}
+}
+
+func TestAppendMapConcurrentClear(t *testing.T) {
+	var m AppendMap[int, int]
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for gen := 1; gen <= 200; gen++ {
+			m.Clear()
+			for k := 0; k < 20; k++ {
+				m.LoadOrStore(k, gen)
+			}
+		}
+		close(stop)
+	}()
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				// All values within a generation are the same, so a consistent view never mixes values.
+				gen := -1
+				m.Range(func(k, v int) bool {
+					if gen == -1 {
+						gen = v
+					} else if v != gen {
+						t.Errorf("Range mixed generations %d and %d", gen, v)
+						return false
+					}
+					m.Load(k)
+					return true
+				})
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestAppendMapStats(t *testing.T) {
+	m := AppendMap[string, int]{CountFastHits: true}
+	m.LoadOrStore("a", 1)
+	m.LoadOrStore("b", 2)
+	if got, want := m.Stats(), (AppendMapStats{Misses: 2, Unpromoted: 2}); got != want {
+		t.Errorf("Stats() = %+v; want %+v", got, want)
+	}
+	m.Load("a")
+	m.Load("a")
+	m.Load("c")
+	m.Load("a")
+	if got, want := m.Stats(), (AppendMapStats{FastHits: 1, SlowHits: 2, Misses: 3, Promotions: 1}); got != want {
+		t.Errorf("Stats() = %+v; want %+v", got, want)
+	}
+	m.LoadOrStore("c", 3)
+	if got, want := m.Stats(), (AppendMapStats{FastHits: 1, SlowHits: 2, Misses: 4, Promotions: 1, Copies: 1, Unpromoted: 3}); got != want {
+		t.Errorf("Stats() = %+v; want %+v", got, want)
+	}
+}
+
+func TestAppendMapPromotionPolicy(t *testing.T) {
+	var calls int
+	m := AppendMap[string, int]{
+		PromotionPolicy: func(slowHits, size int) bool {
+			calls++
+			return slowHits >= 3
+		},
+	}
+	m.LoadOrStore("a", 1)
+	m.Load("a")
+	m.Load("a")
+	if got := m.Stats().Promotions; got != 0 {
+		t.Errorf("map was promoted after 2 slow hits")
+	}
+	m.Load("a")
+	if got := m.Stats().Promotions; got != 1 {
+		t.Errorf("map wasn't promoted after 3 slow hits")
+	}
+	if calls != 3 {
+		t.Errorf("PromotionPolicy was called %d times; want 3", calls)
+	}
+	if !PromoteAtRatio(0.5)(5, 10) || PromoteAtRatio(0.5)(4, 10) {
+		t.Errorf("PromoteAtRatio(0.5) didn't promote at exactly half")
+	}
+}
+
+func TestAppendMapJSON(t *testing.T) {
+	var m AppendMap[point, int]
+	if b, err := json.Marshal(&m); err != nil || string(b) != "{}" {
+		t.Errorf("json.Marshal(empty) = %s, %v; want {}", b, err)
+	}
+	m.LoadOrStore(point{2, 0}, 2)
+	m.LoadOrStore(point{1, 0}, 1)
+	b, err := json.Marshal(&m)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %v", err)
+	}
+	if want := `{"1,0":1,"2,0":2}`; string(b) != want {
+		t.Errorf("json.Marshal() = %s; want %s", b, want)
+	}
+
+	var n AppendMap[point, int]
+	n.LoadOrStore(point{9, 9}, 9)
+	if err := json.Unmarshal(b, &n); err != nil {
+		t.Fatalf("json.Unmarshal failed: %v", err)
+	}
+	if got := n.Snapshot(); len(got) != 2 || got[point{1, 0}] != 1 || got[point{2, 0}] != 2 {
+		t.Errorf("contents after Unmarshal = %v; want the marshaled map", got)
+	}
+	if v, loaded := n.LoadOrStore(point{1, 0}, 5); !loaded || v != 1 {
+		t.Errorf("LoadOrStore(1,0) after Unmarshal = %d, %v; want 1, true", v, loaded)
+	}
+}
@@ -0,0 +1,192 @@
+//go:build go1.19
+
+package mapz
+
+import (
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+// benchMap is the subset of methods that all concurrent maps in this package have in common.
+type benchMap interface {
+	Load(key string) (int, bool)
+	LoadOrStore(key string, value int) (int, bool)
+}
+
+var benchKeys = func() []string {
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+	return keys
+}()
+
+var benchMaps = []struct {
+	name string
+	new  func() benchMap
+}{
+	{"MutexMap", func() benchMap { return &MutexMap[string, int]{} }},
+	{"SyncMap", func() benchMap { return &SyncMap[string, int]{} }},
+	{"AppendMap", func() benchMap { return &AppendMap[string, int]{} }},
+	{"COWMap", func() benchMap { return &COWMap[string, int]{} }},
+	{"ConcurrentSortedMap", func() benchMap { return &ConcurrentSortedMap[string, int]{} }},
+	{"ShardedMap", func() benchMap { return &ShardedMap[string, int]{} }},
+	{"LRUMap", func() benchMap { return NewLRUMap[string, int](2 * len(benchKeys)) }},
+}
+
+func BenchmarkLoad(b *testing.B) {
+	for _, bm := range benchMaps {
+		b.Run(bm.name, func(b *testing.B) {
+			m := bm.new()
+			for i, k := range benchKeys {
+				m.LoadOrStore(k, i)
+			}
+			for i := 0; i < 3*len(benchKeys); i++ {
+				// Give AppendMap a chance to promote.
+				m.Load(benchKeys[i%len(benchKeys)])
+			}
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					m.Load(benchKeys[i%len(benchKeys)])
+					i++
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkLoadOrStore(b *testing.B) {
+	for _, bm := range benchMaps {
+		b.Run(bm.name, func(b *testing.B) {
+			m := bm.new()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					m.LoadOrStore(benchKeys[i%len(benchKeys)], i)
+					i++
+				}
+			})
+		})
+	}
+}
+
+// storeMap is implemented by the maps that allow overwriting values.
+type storeMap interface {
+	Load(key string) (int, bool)
+	Store(key string, value int)
+}
+
+func BenchmarkMixed(b *testing.B) {
+	for _, bm := range []struct {
+		name string
+		new  func() storeMap
+	}{
+		{"MutexMap", func() storeMap { return &MutexMap[string, int]{} }},
+		{"SyncMap", func() storeMap { return &SyncMap[string, int]{} }},
+		{"COWMap", func() storeMap { return &COWMap[string, int]{} }},
+		{"ConcurrentSortedMap", func() storeMap { return &ConcurrentSortedMap[string, int]{} }},
+		{"ShardedMap", func() storeMap { return &ShardedMap[string, int]{} }},
+		{"LRUMap", func() storeMap { return NewLRUMap[string, int](2 * len(benchKeys)) }},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			m := bm.new()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					k := benchKeys[i%len(benchKeys)]
+					if i%10 == 0 {
+						m.Store(k, i)
+					} else {
+						m.Load(k)
+					}
+					i++
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkReadMostly stores one value per 10000 operations, which is the workload COWMap is intended for.
+func BenchmarkReadMostly(b *testing.B) {
+	for _, bm := range []struct {
+		name string
+		new  func() storeMap
+	}{
+		{"MutexMap", func() storeMap { return &MutexMap[string, int]{} }},
+		{"SyncMap", func() storeMap { return &SyncMap[string, int]{} }},
+		{"COWMap", func() storeMap { return &COWMap[string, int]{} }},
+		{"ShardedMap", func() storeMap { return &ShardedMap[string, int]{} }},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			m := bm.new()
+			for i, k := range benchKeys {
+				m.Store(k, i)
+			}
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					k := benchKeys[i%len(benchKeys)]
+					if i%10000 == 0 {
+						m.Store(k, i)
+					} else {
+						m.Load(k)
+					}
+					i++
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkLRUMapEvicting(b *testing.B) {
+	m := NewLRUMap[string, int](len(benchKeys) / 2)
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			m.LoadOrStore(benchKeys[i%len(benchKeys)], i)
+			i++
+		}
+	})
+}
+
+// BenchmarkAppendMapPromotionPolicy runs a write-bursty workload where every 16th operation stores a new key, and the others load recently stored keys.
+func BenchmarkAppendMapPromotionPolicy(b *testing.B) {
+	for _, bm := range []struct {
+		name   string
+		policy PromotionPolicy
+	}{
+		{"ratio=0.25", PromoteAtRatio(0.25)},
+		{"ratio=1", PromoteAtRatio(1)},
+		{"ratio=4", PromoteAtRatio(4)},
+		{"ratio=16", PromoteAtRatio(16)},
+		{"every100slowhits", func(slowHits, size int) bool { return slowHits >= 100 }},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			m := AppendMap[int, int]{PromotionPolicy: bm.policy}
+			for i := 0; i < 1024; i++ {
+				m.LoadOrStore(i, i)
+			}
+			var next atomic.Int64
+			next.Store(1024)
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					if i%16 == 0 {
+						n := next.Add(1)
+						m.LoadOrStore(int(n), i)
+					} else {
+						m.Load(int(next.Load()) - i%64)
+					}
+					i++
+				}
+			})
+			s := m.Stats()
+			b.ReportMetric(float64(s.Copies)/float64(b.N), "copies/op")
+			b.ReportMetric(float64(s.SlowHits)/float64(b.N), "slowhits/op")
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package mapz
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrConflict is returned by BiMap.Store when the value is already mapped to a different key.
+var ErrConflict = errors.New("mapz: value is already mapped to a different key")
+
+// ConflictPolicy decides what BiMap.Store does when the value is already mapped to a different key.
+type ConflictPolicy int
+
+const (
+	// ConflictError makes Store return an error wrapping ErrConflict and leave the map unchanged.
+	ConflictError ConflictPolicy = iota
+	// ConflictOverwrite makes Store delete the other key, so the value is mapped to the new key.
+	ConflictOverwrite
+	// ConflictPanic makes Store panic.
+	ConflictPanic
+)
+
+// BiMap is a bidirectional map: every key maps to one value and every value to one key, so it can be looked up in both directions.
+// Storing a new value for an existing key replaces it like a regular map does. Storing a value that belongs to a different key is a conflict, which is handled according to OnConflict.
+// It isn't safe for concurrent use. Use MutexBiMap if you need that.
+// The zero value is valid.
+type BiMap[K, V comparable] struct {
+	// OnConflict must be set before first use. The zero value is ConflictError.
+	OnConflict ConflictPolicy
+
+	forward  map[K]V
+	backward map[V]K
+}
+
+func (m *BiMap[K, V]) lazyInit() {
+	if m.forward == nil {
+		m.forward = map[K]V{}
+		m.backward = map[V]K{}
+	}
+}
+
+// Store maps key to value and value to key. If key had another value, that value is removed.
+// If value is mapped to a different key, OnConflict decides what happens. An error is only returned for ConflictError.
+func (m *BiMap[K, V]) Store(key K, value V) error {
+	if k, ok := m.backward[value]; ok && k != key {
+		switch m.OnConflict {
+		case ConflictOverwrite:
+			delete(m.forward, k)
+		case ConflictPanic:
+			panic(fmt.Sprintf("mapz: BiMap.Store(%v, %v): value is already mapped to %v", key, value, k))
+		default:
+			return fmt.Errorf("%w: %v is mapped to %v", ErrConflict, value, k)
+		}
+	}
+	m.lazyInit()
+	if v, ok := m.forward[key]; ok {
+		delete(m.backward, v)
+	}
+	m.forward[key] = value
+	m.backward[value] = key
+	return nil
+}
+
+// LoadByKey returns the value for key. The ok result indicates whether key was found in the map.
+func (m *BiMap[K, V]) LoadByKey(key K) (V, bool) {
+	v, ok := m.forward[key]
+	return v, ok
+}
+
+// LoadByValue returns the key for value. The ok result indicates whether value was found in the map.
+func (m *BiMap[K, V]) LoadByValue(value V) (K, bool) {
+	k, ok := m.backward[value]
+	return k, ok
+}
+
+// DeleteByKey deletes key and its value, returning the value if any. The second result reports whether the key was present.
+func (m *BiMap[K, V]) DeleteByKey(key K) (V, bool) {
+	v, ok := m.forward[key]
+	if ok {
+		delete(m.forward, key)
+		delete(m.backward, v)
+	}
+	return v, ok
+}
+
+// DeleteByValue deletes value and its key, returning the key if any. The second result reports whether the value was present.
+func (m *BiMap[K, V]) DeleteByValue(value V) (K, bool) {
+	k, ok := m.backward[value]
+	if ok {
+		delete(m.backward, value)
+		delete(m.forward, k)
+	}
+	return k, ok
+}
+
+// Inverse returns a view of the map with keys and values swapped. Changes to the view are visible in m and vice versa.
+// The view gets a copy of m's OnConflict, which can be changed independently.
+func (m *BiMap[K, V]) Inverse() *BiMap[V, K] {
+	m.lazyInit()
+	return &BiMap[V, K]{
+		OnConflict: m.OnConflict,
+		forward:    m.backward,
+		backward:   m.forward,
+	}
+}
+
+// Len returns the number of elements in the map.
+func (m *BiMap[K, V]) Len() int {
+	return len(m.forward)
+}
+
+// Range calls f sequentially for each key and value present in the map. If f returns false, range stops the iteration.
+// f may modify the map, with the same guarantees as ranging over a regular map.
+func (m *BiMap[K, V]) Range(f func(key K, value V) bool) {
+	for k, v := range m.forward {
+		if !f(k, v) {
+			return
+		}
+	}
+}
+
+// MutexBiMap is a BiMap protected with a mutex. Set M.OnConflict before first use to choose the ConflictPolicy.
+// The zero value is valid.
+//
+// Unlike the other mutex wrappers, the lock isn't exported, because a view returned by Inverse shares the data and the lock of the map it was created from.
+// After first use, only access M from within WithLock.
+type MutexBiMap[K, V comparable] struct {
+	M BiMap[K, V]
+
+	l sync.Mutex
+	// shared is the lock of the original map if this is an Inverse view, and nil otherwise.
+	shared *sync.Mutex
+}
+
+func (m *MutexBiMap[K, V]) lock() *sync.Mutex {
+	if m.shared != nil {
+		return m.shared
+	}
+	return &m.l
+}
+
+// Store maps key to value and value to key. If key had another value, that value is removed.
+// If value is mapped to a different key, M.OnConflict decides what happens. An error is only returned for ConflictError.
+func (m *MutexBiMap[K, V]) Store(key K, value V) error {
+	l := m.lock()
+	l.Lock()
+	defer l.Unlock()
+	return m.M.Store(key, value)
+}
+
+// LoadByKey returns the value for key. The ok result indicates whether key was found in the map.
+func (m *MutexBiMap[K, V]) LoadByKey(key K) (V, bool) {
+	l := m.lock()
+	l.Lock()
+	defer l.Unlock()
+	return m.M.LoadByKey(key)
+}
+
+// LoadByValue returns the key for value. The ok result indicates whether value was found in the map.
+func (m *MutexBiMap[K, V]) LoadByValue(value V) (K, bool) {
+	l := m.lock()
+	l.Lock()
+	defer l.Unlock()
+	return m.M.LoadByValue(value)
+}
+
+// DeleteByKey deletes key and its value, returning the value if any. The second result reports whether the key was present.
+func (m *MutexBiMap[K, V]) DeleteByKey(key K) (V, bool) {
+	l := m.lock()
+	l.Lock()
+	defer l.Unlock()
+	return m.M.DeleteByKey(key)
+}
+
+// DeleteByValue deletes value and its key, returning the key if any. The second result reports whether the value was present.
+func (m *MutexBiMap[K, V]) DeleteByValue(value V) (K, bool) {
+	l := m.lock()
+	l.Lock()
+	defer l.Unlock()
+	return m.M.DeleteByValue(value)
+}
+
+// Inverse returns a view of the map with keys and values swapped. The view shares the data and the lock with m, see MutexBiMap.
+// The view gets a copy of m's M.OnConflict, which can be changed independently before the view is used.
+func (m *MutexBiMap[K, V]) Inverse() *MutexBiMap[V, K] {
+	l := m.lock()
+	l.Lock()
+	defer l.Unlock()
+	return &MutexBiMap[V, K]{
+		M:      *m.M.Inverse(),
+		shared: l,
+	}
+}
+
+// Len returns the number of elements in the map.
+func (m *MutexBiMap[K, V]) Len() int {
+	l := m.lock()
+	l.Lock()
+	defer l.Unlock()
+	return m.M.Len()
+}
+
+// WithLock calls f while holding the lock. f can manipulate the given map at will, but can't use m's regular functions because it's already holding the lock itself.
+func (m *MutexBiMap[K, V]) WithLock(f func(m *BiMap[K, V])) {
+	l := m.lock()
+	l.Lock()
+	defer l.Unlock()
+	f(&m.M)
+}
+
+// Range calls f sequentially for each key and value present in the map. If f returns false, range stops the iteration.
+//
+// Range does not block other methods on the receiver; even f itself may call any method on m.
+//
+// Range repeatedly picks up and drops the mutex so f() won't be called with the mutex held. Use WithLock if you need more performance at the cost of blocking other users.
+func (m *MutexBiMap[K, V]) Range(f func(key K, value V) bool) {
+	l := m.lock()
+	l.Lock()
+	for k, v := range m.M.forward {
+		l.Unlock()
+		if !f(k, v) {
+			return
+		}
+		l.Lock()
+	}
+	l.Unlock()
+}
@@ -0,0 +1,149 @@
+package mapz
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// checkBiMap verifies that both directions of the map agree.
+func checkBiMap[K, V comparable](t *testing.T, m *BiMap[K, V]) {
+	t.Helper()
+	if len(m.forward) != len(m.backward) {
+		t.Fatalf("BiMap has %d keys and %d values", len(m.forward), len(m.backward))
+	}
+	for k, v := range m.forward {
+		if k2, ok := m.backward[v]; !ok || k2 != k {
+			t.Fatalf("BiMap maps %v to %v, but %v to %v", k, v, v, k2)
+		}
+	}
+}
+
+func TestBiMap(t *testing.T) {
+	var m BiMap[int, string]
+	if err := m.Store(1, "one"); err != nil {
+		t.Fatalf("Store(1, one) failed: %v", err)
+	}
+	m.Store(2, "two")
+	if err := m.Store(1, "one"); err != nil {
+		t.Errorf("Store(1, one) again failed: %v", err)
+	}
+	if v, ok := m.LoadByKey(1); !ok || v != "one" {
+		t.Errorf("LoadByKey(1) = %q, %v; want one, true", v, ok)
+	}
+	if k, ok := m.LoadByValue("two"); !ok || k != 2 {
+		t.Errorf("LoadByValue(two) = %d, %v; want 2, true", k, ok)
+	}
+
+	// Replacing the value of a key frees up the old value.
+	m.Store(1, "uno")
+	if _, ok := m.LoadByValue("one"); ok {
+		t.Errorf("LoadByValue(one) succeeded after replacing it")
+	}
+	if err := m.Store(3, "uno"); !errors.Is(err, ErrConflict) {
+		t.Errorf("Store(3, uno) = %v; want ErrConflict", err)
+	}
+	if _, ok := m.LoadByKey(3); ok || m.Len() != 2 {
+		t.Errorf("failed Store(3, uno) modified the map")
+	}
+	checkBiMap(t, &m)
+
+	if v, ok := m.DeleteByKey(1); !ok || v != "uno" {
+		t.Errorf("DeleteByKey(1) = %q, %v; want uno, true", v, ok)
+	}
+	if k, ok := m.DeleteByValue("two"); !ok || k != 2 {
+		t.Errorf("DeleteByValue(two) = %d, %v; want 2, true", k, ok)
+	}
+	if _, ok := m.DeleteByValue("two"); ok {
+		t.Errorf("DeleteByValue(two) succeeded twice")
+	}
+	if m.Len() != 0 {
+		t.Errorf("Len() = %d; want 0", m.Len())
+	}
+	checkBiMap(t, &m)
+}
+
+func TestBiMapConflictPolicy(t *testing.T) {
+	m := BiMap[int, string]{OnConflict: ConflictOverwrite}
+	m.Store(1, "one")
+	if err := m.Store(2, "one"); err != nil {
+		t.Fatalf("Store(2, one) failed: %v", err)
+	}
+	if _, ok := m.LoadByKey(1); ok {
+		t.Errorf("ConflictOverwrite didn't delete the old key")
+	}
+	if k, _ := m.LoadByValue("one"); k != 2 || m.Len() != 1 {
+		t.Errorf("LoadByValue(one) = %d with Len() %d; want 2 with Len() 1", k, m.Len())
+	}
+	checkBiMap(t, &m)
+
+	m.OnConflict = ConflictPanic
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Store with ConflictPanic didn't panic")
+		}
+		checkBiMap(t, &m)
+	}()
+	m.Store(3, "one")
+}
+
+func TestBiMapInverse(t *testing.T) {
+	var m BiMap[int, string]
+	inv := m.Inverse()
+	m.Store(1, "one")
+	inv.Store("two", 2)
+	if v, _ := m.LoadByKey(2); v != "two" {
+		t.Errorf("LoadByKey(2) = %q; want two", v)
+	}
+	if k, _ := inv.LoadByValue(1); k != "one" {
+		t.Errorf("Inverse().LoadByValue(1) = %q; want one", k)
+	}
+	inv.DeleteByKey("one")
+	if m.Len() != 1 || inv.Len() != 1 {
+		t.Errorf("Len() = %d and Inverse().Len() = %d; want 1", m.Len(), inv.Len())
+	}
+	if m.Inverse().Inverse().Len() != 1 {
+		t.Errorf("Inverse().Inverse() doesn't share the map")
+	}
+	checkBiMap(t, &m)
+}
+
+func TestMutexBiMapConcurrency(t *testing.T) {
+	m := MutexBiMap[int, string]{M: BiMap[int, string]{OnConflict: ConflictOverwrite}}
+	inv := m.Inverse()
+	inv.WithLock(func(bm *BiMap[string, int]) {
+		if m.l.TryLock() {
+			t.Errorf("Inverse().WithLock doesn't hold the lock of the original map")
+			m.l.Unlock()
+		}
+		if bm.OnConflict != ConflictOverwrite {
+			t.Errorf("Inverse() didn't copy OnConflict")
+		}
+	})
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				if w%2 == 0 {
+					m.Store(i%50, strconv.Itoa((i+w)%30))
+				} else {
+					inv.Store(strconv.Itoa(i%30), (i+w)%50)
+					inv.DeleteByValue(i % 7)
+				}
+				m.Range(func(k int, v string) bool {
+					return true
+				})
+			}
+		}(w)
+	}
+	wg.Wait()
+	m.WithLock(func(bm *BiMap[int, string]) {
+		checkBiMap(t, bm)
+	})
+	if m.Len() != inv.Len() || m.Len() > 30 {
+		t.Errorf("Len() = %d and Inverse().Len() = %d; want equal and at most 30", m.Len(), inv.Len())
+	}
+}
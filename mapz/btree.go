@@ -0,0 +1,547 @@
+package mapz
+
+import "golang.org/x/exp/constraints"
+
+// btreeDegree is the minimum degree of the B-tree: nodes other than the root have between btreeDegree-1 and 2*btreeDegree-1 keys.
+const btreeDegree = 16
+
+// btree is a B-tree in which every node knows the size of its subtree, so that Rank and Select are O(log n) too.
+// It is the implementation of SortedMap and SortedMapFunc.
+type btree[K, V any] struct {
+	cmp  func(a, b K) int
+	root *btreeNode[K, V]
+	// version is incremented on every insertion and deletion, so iterators know when to find their place again.
+	version uint64
+}
+
+type btreeNode[K, V any] struct {
+	keys   []K
+	values []V
+	// children is nil for leaves. Otherwise it has one element more than keys.
+	children []*btreeNode[K, V]
+	// size is the number of keys in this subtree.
+	size int
+}
+
+func (n *btreeNode[K, V]) leaf() bool {
+	return n.children == nil
+}
+
+// compareOrdered is cmp.Compare, which was only added in Go 1.21. NaNs are less than any other value.
+func compareOrdered[T constraints.Ordered](a, b T) int {
+	aNaN := a != a
+	bNaN := b != b
+	switch {
+	case aNaN && bNaN:
+		return 0
+	case aNaN || a < b:
+		return -1
+	case bNaN || a > b:
+		return 1
+	}
+	return 0
+}
+
+func sliceInsert[T any](s []T, i int, v T) []T {
+	var zero T
+	s = append(s, zero)
+	copy(s[i+1:], s[i:])
+	s[i] = v
+	return s
+}
+
+func sliceRemove[T any](s []T, i int) []T {
+	copy(s[i:], s[i+1:])
+	return sliceTruncate(s, len(s)-1)
+}
+
+// sliceTruncate returns s[:n], after clearing the rest so it can be garbage collected.
+func sliceTruncate[T any](s []T, n int) []T {
+	var zero T
+	for i := n; i < len(s); i++ {
+		s[i] = zero
+	}
+	return s[:n]
+}
+
+// search returns the index of the first key in n that is not less than key, and whether it is equal to key.
+func (t *btree[K, V]) search(n *btreeNode[K, V], key K) (int, bool) {
+	lo, hi := 0, len(n.keys)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if t.cmp(n.keys[mid], key) < 0 {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo, lo < len(n.keys) && t.cmp(n.keys[lo], key) == 0
+}
+
+// searchAfter returns the index of the first key in n that is greater than key.
+func (t *btree[K, V]) searchAfter(n *btreeNode[K, V], key K) int {
+	i, found := t.search(n, key)
+	if found {
+		i++
+	}
+	return i
+}
+
+// get returns the node and index at which key is stored, or nil if it isn't present.
+func (t *btree[K, V]) get(key K) (*btreeNode[K, V], int) {
+	for n := t.root; n != nil; {
+		i, found := t.search(n, key)
+		if found {
+			return n, i
+		}
+		if n.leaf() {
+			break
+		}
+		n = n.children[i]
+	}
+	return nil, 0
+}
+
+func (t *btree[K, V]) len() int {
+	if t.root == nil {
+		return 0
+	}
+	return t.root.size
+}
+
+func (t *btree[K, V]) load(key K) (V, bool) {
+	if n, i := t.get(key); n != nil {
+		return n.values[i], true
+	}
+	var zero V
+	return zero, false
+}
+
+func (t *btree[K, V]) swap(key K, value V) (previous V, loaded bool) {
+	if n, i := t.get(key); n != nil {
+		previous = n.values[i]
+		n.values[i] = value
+		return previous, true
+	}
+	t.insert(key, value)
+	return previous, false
+}
+
+func (t *btree[K, V]) loadOrStore(key K, value V) (actual V, loaded bool) {
+	if n, i := t.get(key); n != nil {
+		return n.values[i], true
+	}
+	t.insert(key, value)
+	return value, false
+}
+
+// insert adds a key that isn't present yet. Full nodes are split on the way down, so there's always room in the parent for the median of a split.
+func (t *btree[K, V]) insert(key K, value V) {
+	t.version++
+	if t.root == nil {
+		t.root = &btreeNode[K, V]{keys: []K{key}, values: []V{value}, size: 1}
+		return
+	}
+	if len(t.root.keys) == 2*btreeDegree-1 {
+		r := &btreeNode[K, V]{children: []*btreeNode[K, V]{t.root}, size: t.root.size}
+		r.splitChild(0)
+		t.root = r
+	}
+	n := t.root
+	for {
+		n.size++
+		i, _ := t.search(n, key)
+		if n.leaf() {
+			n.keys = sliceInsert(n.keys, i, key)
+			n.values = sliceInsert(n.values, i, value)
+			return
+		}
+		if len(n.children[i].keys) == 2*btreeDegree-1 {
+			n.splitChild(i)
+			if t.cmp(key, n.keys[i]) > 0 {
+				i++
+			}
+		}
+		n = n.children[i]
+	}
+}
+
+// splitChild splits the full child i of n in two, moving its median key up into n.
+func (n *btreeNode[K, V]) splitChild(i int) {
+	const d = btreeDegree
+	y := n.children[i]
+	z := &btreeNode[K, V]{
+		keys:   append([]K(nil), y.keys[d:]...),
+		values: append([]V(nil), y.values[d:]...),
+	}
+	z.size = len(z.keys)
+	if !y.leaf() {
+		z.children = append([]*btreeNode[K, V](nil), y.children[d:]...)
+		for _, c := range z.children {
+			z.size += c.size
+		}
+		y.children = sliceTruncate(y.children, d)
+	}
+	mk, mv := y.keys[d-1], y.values[d-1]
+	y.keys = sliceTruncate(y.keys, d-1)
+	y.values = sliceTruncate(y.values, d-1)
+	y.size -= z.size + 1
+	n.keys = sliceInsert(n.keys, i, mk)
+	n.values = sliceInsert(n.values, i, mv)
+	n.children = sliceInsert(n.children, i+1, z)
+}
+
+func (t *btree[K, V]) delete(key K) (V, bool) {
+	if t.root == nil {
+		var zero V
+		return zero, false
+	}
+	v, ok := t.deleteFrom(t.root, key)
+	if len(t.root.keys) == 0 {
+		if t.root.leaf() {
+			t.root = nil
+		} else {
+			t.root = t.root.children[0]
+		}
+	}
+	if ok {
+		t.version++
+	}
+	return v, ok
+}
+
+// deleteFrom deletes key from the subtree rooted at n. n must have at least btreeDegree keys, unless it's the root.
+// Children get at least btreeDegree keys before we descend into them, so the deletion never has to walk back up.
+func (t *btree[K, V]) deleteFrom(n *btreeNode[K, V], key K) (V, bool) {
+	i, found := t.search(n, key)
+	if n.leaf() {
+		if !found {
+			var zero V
+			return zero, false
+		}
+		v := n.values[i]
+		n.keys = sliceRemove(n.keys, i)
+		n.values = sliceRemove(n.values, i)
+		n.size--
+		return v, true
+	}
+	if found {
+		v := n.values[i]
+		switch {
+		case len(n.children[i].keys) >= btreeDegree:
+			// Replace the key with its predecessor, and delete that from the left subtree.
+			p := n.children[i].max()
+			pk, pv := p.keys[len(p.keys)-1], p.values[len(p.values)-1]
+			t.deleteFrom(n.children[i], pk)
+			n.keys[i], n.values[i] = pk, pv
+		case len(n.children[i+1].keys) >= btreeDegree:
+			// Replace the key with its successor, and delete that from the right subtree.
+			s := n.children[i+1].min()
+			sk, sv := s.keys[0], s.values[0]
+			t.deleteFrom(n.children[i+1], sk)
+			n.keys[i], n.values[i] = sk, sv
+		default:
+			n.merge(i)
+			t.deleteFrom(n.children[i], key)
+		}
+		n.size--
+		return v, true
+	}
+	if len(n.children[i].keys) < btreeDegree {
+		i = n.grow(i)
+	}
+	v, ok := t.deleteFrom(n.children[i], key)
+	if ok {
+		n.size--
+	}
+	return v, ok
+}
+
+// grow makes sure child i of n has at least btreeDegree keys by borrowing a key from a sibling or merging with one. It returns the new index of the child.
+func (n *btreeNode[K, V]) grow(i int) int {
+	c := n.children[i]
+	if i > 0 && len(n.children[i-1].keys) >= btreeDegree {
+		// Rotate a key from the left sibling through n into c.
+		l := n.children[i-1]
+		last := len(l.keys) - 1
+		c.keys = sliceInsert(c.keys, 0, n.keys[i-1])
+		c.values = sliceInsert(c.values, 0, n.values[i-1])
+		n.keys[i-1], n.values[i-1] = l.keys[last], l.values[last]
+		l.keys = sliceTruncate(l.keys, last)
+		l.values = sliceTruncate(l.values, last)
+		c.size++
+		l.size--
+		if !l.leaf() {
+			lc := l.children[len(l.children)-1]
+			l.children = sliceTruncate(l.children, len(l.children)-1)
+			c.children = sliceInsert(c.children, 0, lc)
+			l.size -= lc.size
+			c.size += lc.size
+		}
+		return i
+	}
+	if i < len(n.keys) && len(n.children[i+1].keys) >= btreeDegree {
+		// Rotate a key from the right sibling through n into c.
+		r := n.children[i+1]
+		c.keys = append(c.keys, n.keys[i])
+		c.values = append(c.values, n.values[i])
+		n.keys[i], n.values[i] = r.keys[0], r.values[0]
+		r.keys = sliceRemove(r.keys, 0)
+		r.values = sliceRemove(r.values, 0)
+		c.size++
+		r.size--
+		if !r.leaf() {
+			rc := r.children[0]
+			r.children = sliceRemove(r.children, 0)
+			c.children = append(c.children, rc)
+			r.size -= rc.size
+			c.size += rc.size
+		}
+		return i
+	}
+	if i == len(n.keys) {
+		i--
+	}
+	n.merge(i)
+	return i
+}
+
+// merge merges key i and child i+1 of n into child i.
+func (n *btreeNode[K, V]) merge(i int) {
+	l, r := n.children[i], n.children[i+1]
+	l.keys = append(append(l.keys, n.keys[i]), r.keys...)
+	l.values = append(append(l.values, n.values[i]), r.values...)
+	l.children = append(l.children, r.children...)
+	l.size += 1 + r.size
+	n.keys = sliceRemove(n.keys, i)
+	n.values = sliceRemove(n.values, i)
+	n.children = sliceRemove(n.children, i+1)
+}
+
+// min returns the leaf containing the smallest key of the subtree.
+func (n *btreeNode[K, V]) min() *btreeNode[K, V] {
+	for !n.leaf() {
+		n = n.children[0]
+	}
+	return n
+}
+
+// max returns the leaf containing the largest key of the subtree.
+func (n *btreeNode[K, V]) max() *btreeNode[K, V] {
+	for !n.leaf() {
+		n = n.children[len(n.children)-1]
+	}
+	return n
+}
+
+func (t *btree[K, V]) first() (key K, value V, ok bool) {
+	if t.root == nil {
+		return key, value, false
+	}
+	n := t.root.min()
+	return n.keys[0], n.values[0], true
+}
+
+func (t *btree[K, V]) last() (key K, value V, ok bool) {
+	if t.root == nil {
+		return key, value, false
+	}
+	n := t.root.max()
+	return n.keys[len(n.keys)-1], n.values[len(n.values)-1], true
+}
+
+// floor returns the greatest key less than or equal to key.
+func (t *btree[K, V]) floor(key K) (k K, v V, ok bool) {
+	for n := t.root; n != nil; {
+		i, found := t.search(n, key)
+		if found {
+			return n.keys[i], n.values[i], true
+		}
+		if i > 0 {
+			// The subtree below might still have a better candidate.
+			k, v, ok = n.keys[i-1], n.values[i-1], true
+		}
+		if n.leaf() {
+			break
+		}
+		n = n.children[i]
+	}
+	return k, v, ok
+}
+
+// ceiling returns the least key greater than or equal to key.
+func (t *btree[K, V]) ceiling(key K) (k K, v V, ok bool) {
+	for n := t.root; n != nil; {
+		i, found := t.search(n, key)
+		if found {
+			return n.keys[i], n.values[i], true
+		}
+		if i < len(n.keys) {
+			k, v, ok = n.keys[i], n.values[i], true
+		}
+		if n.leaf() {
+			break
+		}
+		n = n.children[i]
+	}
+	return k, v, ok
+}
+
+// rank returns the number of keys less than key.
+func (t *btree[K, V]) rank(key K) int {
+	r := 0
+	for n := t.root; n != nil; {
+		i, found := t.search(n, key)
+		r += i
+		if n.leaf() {
+			break
+		}
+		for _, c := range n.children[:i] {
+			r += c.size
+		}
+		if found {
+			r += n.children[i].size
+			break
+		}
+		n = n.children[i]
+	}
+	return r
+}
+
+// selectAt returns the key with the given rank.
+func (t *btree[K, V]) selectAt(rank int) (key K, value V, ok bool) {
+	if rank < 0 || rank >= t.len() {
+		return key, value, false
+	}
+	n := t.root
+outer:
+	for !n.leaf() {
+		for i, c := range n.children {
+			if rank < c.size {
+				n = c
+				continue outer
+			}
+			rank -= c.size
+			if rank == 0 {
+				return n.keys[i], n.values[i], true
+			}
+			rank--
+		}
+	}
+	return n.keys[rank], n.values[rank], true
+}
+
+type btreeFrame[K, V any] struct {
+	n *btreeNode[K, V]
+	i int
+}
+
+// ascend calls f for the keys in [from, to) in ascending order. A nil bound means unbounded.
+// If f inserts or deletes keys, ascend finds its place again by looking up the key it visited last.
+func (t *btree[K, V]) ascend(from, to *K, f func(key K, value V) bool) {
+	var stack []btreeFrame[K, V]
+	var last K
+	started := false
+	seek := func() {
+		// Every frame points at the next key of its node to visit, after its children left of that key have been visited.
+		stack = stack[:0]
+		for n := t.root; n != nil; {
+			i := 0
+			if started {
+				i = t.searchAfter(n, last)
+			} else if from != nil {
+				i, _ = t.search(n, *from)
+			}
+			stack = append(stack, btreeFrame[K, V]{n, i})
+			if n.leaf() {
+				break
+			}
+			n = n.children[i]
+		}
+	}
+	seek()
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		if top.i >= len(top.n.keys) {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		n, i := top.n, top.i
+		k, v := n.keys[i], n.values[i]
+		if to != nil && t.cmp(k, *to) >= 0 {
+			return
+		}
+		top.i++
+		if !n.leaf() {
+			for c := n.children[i+1]; ; c = c.children[0] {
+				stack = append(stack, btreeFrame[K, V]{c, 0})
+				if c.leaf() {
+					break
+				}
+			}
+		}
+		version := t.version
+		if !f(k, v) {
+			return
+		}
+		if t.version != version {
+			last, started = k, true
+			seek()
+		}
+	}
+}
+
+// descend calls f for the keys in [from, to) in descending order. A nil bound means unbounded.
+// If f inserts or deletes keys, descend finds its place again by looking up the key it visited last.
+func (t *btree[K, V]) descend(from, to *K, f func(key K, value V) bool) {
+	var stack []btreeFrame[K, V]
+	var last K
+	started := false
+	seek := func() {
+		// Every frame points just past the next key of its node to visit, after its children right of that key have been visited.
+		stack = stack[:0]
+		for n := t.root; n != nil; {
+			i := len(n.keys)
+			if started {
+				i, _ = t.search(n, last)
+			} else if to != nil {
+				i, _ = t.search(n, *to)
+			}
+			stack = append(stack, btreeFrame[K, V]{n, i})
+			if n.leaf() {
+				break
+			}
+			n = n.children[i]
+		}
+	}
+	seek()
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		if top.i == 0 {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		top.i--
+		n, i := top.n, top.i
+		k, v := n.keys[i], n.values[i]
+		if from != nil && t.cmp(k, *from) < 0 {
+			return
+		}
+		if !n.leaf() {
+			for c := n.children[i]; ; c = c.children[len(c.children)-1] {
+				stack = append(stack, btreeFrame[K, V]{c, len(c.keys)})
+				if c.leaf() {
+					break
+				}
+			}
+		}
+		version := t.version
+		if !f(k, v) {
+			return
+		}
+		if t.version != version {
+			last, started = k, true
+			seek()
+		}
+	}
+}
@@ -0,0 +1,119 @@
+//go:build go1.19
+
+package mapz
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+type bulkMap interface {
+	Load(key string) (int, bool)
+	StoreAll(entries map[string]int)
+	LoadMany(keys ...string) map[string]int
+	DeleteMany(keys ...string)
+	LoadAndDeleteAll() map[string]int
+}
+
+func TestBulkOperations(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		m    bulkMap
+	}{
+		{"MutexMap", &MutexMap[string, int]{}},
+		{"RWMutexMap", &RWMutexMap[string, int]{}},
+		{"UnsyncedMap", &UnsyncedMap[string, int]{}},
+		{"SyncMap", &SyncMap[string, int]{}},
+		{"AppendMap", &AppendMap[string, int]{}},
+		{"COWMap", &COWMap[string, int]{}},
+		{"LinkedMap", &LinkedMap[string, int]{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := tc.m
+			m.StoreAll(map[string]int{"a": 1, "b": 2, "c": 3})
+			m.StoreAll(map[string]int{"d": 4})
+			if got, want := m.LoadMany("a", "c", "e"), map[string]int{"a": 1, "c": 3}; !reflect.DeepEqual(got, want) {
+				t.Errorf("LoadMany(a, c, e) = %v; want %v", got, want)
+			}
+			m.DeleteMany("a", "e")
+			if _, ok := m.Load("a"); ok {
+				t.Errorf("DeleteMany didn't delete a")
+			}
+			if got, want := m.LoadAndDeleteAll(), map[string]int{"b": 2, "c": 3, "d": 4}; !reflect.DeepEqual(got, want) {
+				t.Errorf("LoadAndDeleteAll() = %v; want %v", got, want)
+			}
+			if got := m.LoadMany("b", "c", "d"); len(got) != 0 {
+				t.Errorf("LoadMany after LoadAndDeleteAll = %v; want nothing", got)
+			}
+		})
+	}
+}
+
+func TestLoadAndDeleteAllDuringRange(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		m    interface {
+			bulkMap
+			Range(f func(key string, value int) bool)
+		}
+	}{
+		{"MutexMap", &MutexMap[string, int]{}},
+		{"RWMutexMap", &RWMutexMap[string, int]{}},
+		{"AppendMap", &AppendMap[string, int]{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := tc.m
+			entries := map[string]int{}
+			for i := 0; i < 100; i++ {
+				entries[strconv.Itoa(i)] = i
+			}
+			m.StoreAll(entries)
+			inRange := make(chan struct{})
+			deleted := make(chan struct{})
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				first := true
+				m.Range(func(k string, v int) bool {
+					if first {
+						first = false
+						close(inRange)
+						<-deleted
+					}
+					return true
+				})
+			}()
+			<-inRange
+			got := m.LoadAndDeleteAll()
+			close(deleted)
+			// The caller owns the returned map, so modifying it must not race with the Range that's still iterating.
+			for k := range got {
+				delete(got, k)
+			}
+			<-done
+		})
+	}
+}
+
+func TestAppendMapStoreAll(t *testing.T) {
+	var m AppendMap[string, int]
+	m.LoadOrStore("a", 1)
+	m.Snapshot()
+	before := m.Stats()
+	m.StoreAll(map[string]int{"a": 10, "b": 2, "c": 3, "d": 4})
+	after := m.Stats()
+	if got := after.Promotions - before.Promotions; got != 1 {
+		t.Errorf("StoreAll caused %d promotions; want 1", got)
+	}
+	if got := after.Copies - before.Copies; got != 1 {
+		t.Errorf("StoreAll caused %d copies; want 1", got)
+	}
+	if got, want := m.Snapshot(), map[string]int{"a": 1, "b": 2, "c": 3, "d": 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Snapshot() = %v; want %v", got, want)
+	}
+	m.StoreAll(map[string]int{"a": 10})
+	if got := m.Stats().Promotions; got != after.Promotions {
+		t.Errorf("StoreAll of only existing keys caused a promotion")
+	}
+}
@@ -0,0 +1,182 @@
+package mapz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// ErrComputePanicked is wrapped by the *ComputePanicError returned to callers that were waiting for a computation that panicked. Use errors.Is to check for it.
+var ErrComputePanicked = errors.New("mapz: LoadOrCompute function panicked")
+
+// ComputePanicError is returned to callers that were waiting for a LoadOrCompute function that panicked. LoadOrComputeContext also panics with it in the caller that started the computation.
+type ComputePanicError struct {
+	// Value is the value the function panicked with.
+	Value any
+	// Stack is the stack trace of the goroutine that panicked, as returned by debug.Stack.
+	Stack []byte
+}
+
+func (e *ComputePanicError) Error() string {
+	return fmt.Sprintf("%v: %v\n\n%s", ErrComputePanicked, e.Value, e.Stack)
+}
+
+func (e *ComputePanicError) Unwrap() error {
+	return ErrComputePanicked
+}
+
+// computeCall is an in-flight LoadOrCompute call. Other callers for the same key wait for done to be closed and then read value and err.
+type computeCall[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+
+	// waiters is the number of callers waiting for this call. It is protected by the map's lock.
+	waiters int
+	// cancel cancels the context passed to the function. It is nil for calls started by LoadOrCompute, which can't be abandoned.
+	cancel context.CancelFunc
+}
+
+// register returns the in-flight call for key, or registers a new one if there is none. The caller is counted as a waiter. l must be held.
+func register[K comparable, V any](calls *map[K]*computeCall[V], key K) (c *computeCall[V], started bool) {
+	if c, ok := (*calls)[key]; ok {
+		c.waiters++
+		return c, false
+	}
+	c = &computeCall[V]{done: make(chan struct{}), waiters: 1}
+	if *calls == nil {
+		*calls = map[K]*computeCall[V]{}
+	}
+	(*calls)[key] = c
+	return c, true
+}
+
+// unregister removes c from the in-flight calls unless it was already removed. It returns whether c was still registered. l must be held.
+func unregister[K comparable, V any](calls *map[K]*computeCall[V], key K, c *computeCall[V]) bool {
+	if (*calls)[key] != c {
+		return false
+	}
+	delete(*calls, key)
+	return true
+}
+
+// finish stores the result of c (if it succeeded) and wakes up all waiters.
+// The result is not stored if c was abandoned by all its waiters or invalidated by the map (for example by AppendMap.Clear) in the meantime.
+func finish[K comparable, V any](l sync.Locker, calls *map[K]*computeCall[V], key K, c *computeCall[V], store func(K, V) V, v V, err error) (V, error) {
+	l.Lock()
+	if unregister(calls, key, c) && err == nil {
+		v = store(key, v)
+	}
+	l.Unlock()
+	c.value, c.err = v, err
+	close(c.done)
+	return v, err
+}
+
+// loadOrCompute implements LoadOrCompute for a map protected by l. calls holds the in-flight computations and is protected by l as well.
+// load and store are called with l held. store should store the value unless another value was stored in the meantime, and return the value that ends up in the map.
+func loadOrCompute[K comparable, V any](l sync.Locker, calls *map[K]*computeCall[V], key K, load func(K) (V, bool), store func(K, V) V, fn func() (V, error)) (V, error) {
+	l.Lock()
+	if v, ok := load(key); ok {
+		l.Unlock()
+		return v, nil
+	}
+	c, started := register(calls, key)
+	l.Unlock()
+	if !started {
+		<-c.done
+		return c.value, c.err
+	}
+
+	finished := false
+	defer func() {
+		if finished {
+			return
+		}
+		// Recover only to tell the waiters what happened, and then continue panicking. r is nil if fn called runtime.Goexit.
+		r := recover()
+		var zero V
+		finish(l, calls, key, c, store, zero, &ComputePanicError{Value: r, Stack: debug.Stack()})
+		if r != nil {
+			panic(r)
+		}
+	}()
+	v, err := fn()
+	finished = true
+	return finish(l, calls, key, c, store, v, err)
+}
+
+// loadOrComputeContext implements LoadOrComputeContext. See loadOrCompute for the arguments.
+// fn runs in its own goroutine so that it can continue when the caller that started it gives up.
+func loadOrComputeContext[K comparable, V any](ctx context.Context, l sync.Locker, calls *map[K]*computeCall[V], key K, load func(K) (V, bool), store func(K, V) V, fn func(ctx context.Context) (V, error)) (V, error) {
+	l.Lock()
+	if v, ok := load(key); ok {
+		l.Unlock()
+		return v, nil
+	}
+	c, started := register(calls, key)
+	if started {
+		fctx, cancel := context.WithCancel(detachedContext{ctx})
+		c.cancel = cancel
+		go func() {
+			defer cancel()
+			finished := false
+			defer func() {
+				if finished {
+					return
+				}
+				// Panicking here would crash the program, so hand the panic to the waiters. The caller that started fn re-panics with it.
+				r := recover()
+				var zero V
+				finish(l, calls, key, c, store, zero, &ComputePanicError{Value: r, Stack: debug.Stack()})
+			}()
+			v, err := fn(fctx)
+			finished = true
+			finish(l, calls, key, c, store, v, err)
+		}()
+	}
+	l.Unlock()
+
+	select {
+	case <-c.done:
+		if pe, ok := c.err.(*ComputePanicError); ok && started {
+			panic(pe)
+		}
+		return c.value, c.err
+	case <-ctx.Done():
+		l.Lock()
+		c.waiters--
+		if c.waiters == 0 && c.cancel != nil {
+			// Nobody is interested anymore. Make sure the next caller starts a fresh computation rather than joining this cancelled one.
+			c.cancel()
+			unregister(calls, key, c)
+		}
+		l.Unlock()
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// detachedContext carries the values of its parent, but not its deadline or cancellation. This is context.WithoutCancel, which was only added in Go 1.21.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key any) any {
+	return c.parent.Value(key)
+}
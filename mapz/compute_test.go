@@ -0,0 +1,274 @@
+//go:build go1.19
+
+package mapz
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type loadOrComputeFunc func(key string, fn func() (int, error)) (int, error)
+
+var loadOrComputeMaps = []struct {
+	name string
+	new  func() loadOrComputeFunc
+}{
+	{"MutexMap", func() loadOrComputeFunc { return (&MutexMap[string, int]{}).LoadOrCompute }},
+	{"SyncMap", func() loadOrComputeFunc { return (&SyncMap[string, int]{}).LoadOrCompute }},
+	{"AppendMap", func() loadOrComputeFunc { return (&AppendMap[string, int]{}).LoadOrCompute }},
+}
+
+func TestLoadOrCompute(t *testing.T) {
+	for _, tc := range loadOrComputeMaps {
+		t.Run(tc.name, func(t *testing.T) {
+			lc := tc.new()
+			errBoom := errors.New("boom")
+			if _, err := lc("a", func() (int, error) { return 0, errBoom }); err != errBoom {
+				t.Errorf("LoadOrCompute returned %v; want %v", err, errBoom)
+			}
+			if v, err := lc("a", func() (int, error) { return 1, nil }); err != nil || v != 1 {
+				t.Errorf("LoadOrCompute after error = %d, %v; want 1, nil", v, err)
+			}
+			if v, err := lc("a", func() (int, error) { return 2, nil }); err != nil || v != 1 {
+				t.Errorf("LoadOrCompute of existing key = %d, %v; want 1, nil", v, err)
+			}
+		})
+	}
+}
+
+func TestLoadOrComputeOnce(t *testing.T) {
+	for _, tc := range loadOrComputeMaps {
+		t.Run(tc.name, func(t *testing.T) {
+			lc := tc.new()
+			var calls int32
+			release := make(chan struct{})
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					v, err := lc("a", func() (int, error) {
+						atomic.AddInt32(&calls, 1)
+						<-release
+						return 42, nil
+					})
+					if err != nil || v != 42 {
+						t.Errorf("LoadOrCompute = %d, %v; want 42, nil", v, err)
+					}
+				}()
+			}
+			close(release)
+			wg.Wait()
+			if calls != 1 {
+				t.Errorf("fn was called %d times; want 1", calls)
+			}
+		})
+	}
+}
+
+func TestLoadOrComputePanic(t *testing.T) {
+	for _, tc := range loadOrComputeMaps {
+		t.Run(tc.name, func(t *testing.T) {
+			lc := tc.new()
+			started := make(chan struct{})
+			release := make(chan struct{})
+			waiterErr := make(chan error)
+			go func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("panic wasn't propagated")
+					}
+				}()
+				lc("a", func() (int, error) {
+					close(started)
+					<-release
+					panic("boom")
+				})
+			}()
+			<-started
+			go func() {
+				_, err := lc("a", func() (int, error) { return 1, nil })
+				waiterErr <- err
+			}()
+			close(release)
+			// The waiter might not have been registered before the panic, in which case it computes the value itself.
+			if err := <-waiterErr; err != nil {
+				checkComputePanicError(t, err)
+			}
+			if v, err := lc("a", func() (int, error) { return 1, nil }); err != nil || v != 1 {
+				t.Errorf("LoadOrCompute after panic = %d, %v; want 1, nil", v, err)
+			}
+		})
+	}
+}
+
+func TestLoadOrComputeContextPanic(t *testing.T) {
+	for _, tc := range loadOrComputeContextMaps {
+		t.Run(tc.name, func(t *testing.T) {
+			lc := tc.new()
+			started := make(chan struct{})
+			release := make(chan struct{})
+			fn := func(ctx context.Context) (int, error) {
+				close(started)
+				<-release
+				panic("boom")
+			}
+			firstPanic := make(chan any)
+			go func() {
+				defer func() {
+					firstPanic <- recover()
+				}()
+				lc(context.Background(), "a", fn)
+			}()
+			<-started
+			waiterErr := make(chan error)
+			go func() {
+				_, err := lc(context.Background(), "a", func(ctx context.Context) (int, error) { return 1, nil })
+				waiterErr <- err
+			}()
+			close(release)
+			if r := <-firstPanic; r == nil {
+				t.Errorf("caller that started fn didn't panic")
+			} else if err, ok := r.(error); !ok {
+				t.Errorf("caller that started fn panicked with %v; want a *ComputePanicError", r)
+			} else {
+				checkComputePanicError(t, err)
+			}
+			// The waiter might not have been registered before the panic, in which case it computes the value itself.
+			if err := <-waiterErr; err != nil {
+				checkComputePanicError(t, err)
+			}
+			if v, err := lc(context.Background(), "a", func(ctx context.Context) (int, error) { return 1, nil }); err != nil || v != 1 {
+				t.Errorf("LoadOrComputeContext after panic = %d, %v; want 1, nil", v, err)
+			}
+		})
+	}
+}
+
+// checkComputePanicError checks that err reports the panic("boom") from the panic tests.
+func checkComputePanicError(t *testing.T, err error) {
+	t.Helper()
+	var pe *ComputePanicError
+	if !errors.As(err, &pe) || !errors.Is(err, ErrComputePanicked) {
+		t.Errorf("got %v; want a *ComputePanicError", err)
+		return
+	}
+	if pe.Value != "boom" {
+		t.Errorf("ComputePanicError.Value = %v; want boom", pe.Value)
+	}
+	if !strings.Contains(string(pe.Stack), "compute_test.go") {
+		t.Errorf("ComputePanicError.Stack doesn't include the panicking function:\n%s", pe.Stack)
+	}
+}
+
+type loadOrComputeContextFunc func(ctx context.Context, key string, fn func(ctx context.Context) (int, error)) (int, error)
+
+var loadOrComputeContextMaps = []struct {
+	name string
+	new  func() loadOrComputeContextFunc
+}{
+	{"MutexMap", func() loadOrComputeContextFunc { return (&MutexMap[string, int]{}).LoadOrComputeContext }},
+	{"SyncMap", func() loadOrComputeContextFunc { return (&SyncMap[string, int]{}).LoadOrComputeContext }},
+	{"AppendMap", func() loadOrComputeContextFunc { return (&AppendMap[string, int]{}).LoadOrComputeContext }},
+}
+
+func TestLoadOrComputeContextWaiterCancelled(t *testing.T) {
+	for _, tc := range loadOrComputeContextMaps {
+		t.Run(tc.name, func(t *testing.T) {
+			lc := tc.new()
+			started := make(chan struct{})
+			release := make(chan struct{})
+			fn := func(ctx context.Context) (int, error) {
+				close(started)
+				select {
+				case <-release:
+					return 42, nil
+				case <-ctx.Done():
+					return 0, ctx.Err()
+				}
+			}
+			res1 := make(chan int)
+			go func() {
+				v, err := lc(context.Background(), "a", fn)
+				if err != nil {
+					t.Errorf("first caller got error %v", err)
+				}
+				res1 <- v
+			}()
+			<-started
+			ctx2, cancel2 := context.WithCancel(context.Background())
+			cancel2()
+			if _, err := lc(ctx2, "a", fn); err != context.Canceled {
+				t.Errorf("cancelled waiter got %v; want %v", err, context.Canceled)
+			}
+			close(release)
+			if v := <-res1; v != 42 {
+				t.Errorf("first caller got %d; want 42", v)
+			}
+			if v, err := lc(context.Background(), "a", fn); err != nil || v != 42 {
+				t.Errorf("LoadOrComputeContext after computation = %d, %v; want 42, nil", v, err)
+			}
+		})
+	}
+}
+
+func TestLoadOrComputeContextAllWaitersCancelled(t *testing.T) {
+	for _, tc := range loadOrComputeContextMaps {
+		t.Run(tc.name, func(t *testing.T) {
+			lc := tc.new()
+			type ctxKey struct{}
+			fnCancelled := make(chan interface{})
+			ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "value"))
+			go func() {
+				<-time.After(10 * time.Millisecond)
+				cancel()
+			}()
+			_, err := lc(ctx, "a", func(ctx context.Context) (int, error) {
+				<-ctx.Done()
+				fnCancelled <- ctx.Value(ctxKey{})
+				return 0, ctx.Err()
+			})
+			if err != context.Canceled {
+				t.Errorf("LoadOrComputeContext returned %v; want %v", err, context.Canceled)
+			}
+			select {
+			case v := <-fnCancelled:
+				if v != "value" {
+					t.Errorf("fn's context didn't carry the caller's values: %v", v)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("fn's context wasn't cancelled after all waiters left")
+			}
+			if v, err := lc(context.Background(), "a", func(ctx context.Context) (int, error) { return 1, nil }); err != nil || v != 1 {
+				t.Errorf("LoadOrComputeContext after abandoned computation = %d, %v; want 1, nil", v, err)
+			}
+		})
+	}
+}
+
+func TestMutexMapComputeCallsReleased(t *testing.T) {
+	m := &MutexMap[string, int]{}
+	m.LoadOrCompute("a", func() (int, error) { return 1, nil })
+	m.LoadOrCompute("b", func() (int, error) { return 0, errors.New("boom") })
+	func() {
+		defer func() { recover() }()
+		m.LoadOrCompute("c", func() (int, error) { panic("boom") })
+	}()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	m.LoadOrComputeContext(ctx, "d", func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	m.L.Lock()
+	n := len(m.calls)
+	m.L.Unlock()
+	if n != 0 {
+		t.Errorf("MutexMap has %d in-flight calls after all computations finished; want 0", n)
+	}
+}
@@ -0,0 +1,443 @@
+//go:build go1.19
+
+package mapz
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/exp/constraints"
+)
+
+// skipListMaxLevel is the number of levels of the skip list. With a branching factor of 4, this is plenty for 4^16 entries.
+const skipListMaxLevel = 16
+
+// ConcurrentSortedMap is a concurrency-safe map that keeps its keys in sorted order. Its interface closely resembles MutexMap, with ordered lookups and scans like SortedMap.
+// It is a lazy skip list: Load, Floor, Ceiling, Min, Max and the Range methods never take a lock, and writers only lock the nodes next to the key they modify, so writers for different parts of the map don't block each other.
+// Load, Store, Delete, Floor and Ceiling are O(log n).
+//
+// Scans are weakly consistent. They visit keys in order and never visit a key twice. Keys that are present during the entire scan are always visited; keys that are inserted or deleted during the scan may or may not be.
+// A visited value is the value at the time it was visited.
+//
+// The zero value is valid. Floating point NaNs are sorted before all other values.
+type ConcurrentSortedMap[K constraints.Ordered, V any] struct {
+	once   sync.Once
+	head   skipNode[K, V]
+	length atomic.Int64
+	seed   atomic.Uint64
+}
+
+var _ Map[string, int] = &ConcurrentSortedMap[string, int]{}
+
+type skipNode[K, V any] struct {
+	key   K
+	value atomic.Pointer[V]
+	// next has an element for every level this node is linked on.
+	next []atomic.Pointer[skipNode[K, V]]
+	// mu protects the next pointers when linking or unlinking neighbours, and value and marked against concurrent writers.
+	mu sync.Mutex
+	// marked is set when the node is being deleted. fullyLinked is set once the node has been linked on all its levels.
+	// A node is in the map if it is fully linked and not marked.
+	marked      atomic.Bool
+	fullyLinked atomic.Bool
+}
+
+func (n *skipNode[K, V]) live() bool {
+	return n.fullyLinked.Load() && !n.marked.Load()
+}
+
+func (m *ConcurrentSortedMap[K, V]) lazyInit() {
+	m.once.Do(func() {
+		m.head.next = make([]atomic.Pointer[skipNode[K, V]], skipListMaxLevel)
+	})
+}
+
+// randomLevel returns the number of levels for a new node. Every level has a quarter of the nodes of the level below.
+func (m *ConcurrentSortedMap[K, V]) randomLevel() int {
+	// splitmix64
+	z := m.seed.Add(0x9e3779b97f4a7c15)
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	z ^= z >> 31
+	level := 1
+	for level < skipListMaxLevel && z&3 == 0 {
+		level++
+		z >>= 2
+	}
+	return level
+}
+
+// find fills preds and succs with the last node before key and the first node at or after key on every level.
+// It returns the highest level on which a node with key was found, or -1.
+func (m *ConcurrentSortedMap[K, V]) find(key K, preds, succs *[skipListMaxLevel]*skipNode[K, V]) int {
+	found := -1
+	pred := &m.head
+	for l := skipListMaxLevel - 1; l >= 0; l-- {
+		curr := pred.next[l].Load()
+		for curr != nil && compareOrdered(curr.key, key) < 0 {
+			pred = curr
+			curr = pred.next[l].Load()
+		}
+		if found == -1 && curr != nil && compareOrdered(curr.key, key) == 0 {
+			found = l
+		}
+		preds[l], succs[l] = pred, curr
+	}
+	return found
+}
+
+// search returns the node with the given key, whether it's live or not. It returns nil if there's no such node.
+func (m *ConcurrentSortedMap[K, V]) search(key K) *skipNode[K, V] {
+	m.lazyInit()
+	pred := &m.head
+	for l := skipListMaxLevel - 1; l >= 0; l-- {
+		for curr := pred.next[l].Load(); curr != nil; curr = pred.next[l].Load() {
+			c := compareOrdered(curr.key, key)
+			if c == 0 {
+				return curr
+			}
+			if c > 0 {
+				break
+			}
+			pred = curr
+		}
+	}
+	return nil
+}
+
+// seek returns the first node with a key greater than or equal to key, whether it's live or not.
+func (m *ConcurrentSortedMap[K, V]) seek(key K) *skipNode[K, V] {
+	m.lazyInit()
+	pred := &m.head
+	var curr *skipNode[K, V]
+	for l := skipListMaxLevel - 1; l >= 0; l-- {
+		curr = pred.next[l].Load()
+		for curr != nil && compareOrdered(curr.key, key) < 0 {
+			pred = curr
+			curr = pred.next[l].Load()
+		}
+	}
+	return curr
+}
+
+// before returns the last live node with a key less than key (or equal to it, if inclusive). If key is nil, it returns the last live node. It returns nil if there is no such node.
+func (m *ConcurrentSortedMap[K, V]) before(key *K, inclusive bool) *skipNode[K, V] {
+	m.lazyInit()
+	for {
+		pred := &m.head
+		for l := skipListMaxLevel - 1; l >= 0; l-- {
+			for curr := pred.next[l].Load(); curr != nil; curr = pred.next[l].Load() {
+				if key != nil {
+					if c := compareOrdered(curr.key, *key); c > 0 || (c == 0 && !inclusive) {
+						break
+					}
+				}
+				pred = curr
+			}
+		}
+		if pred == &m.head {
+			return nil
+		}
+		if pred.live() {
+			return pred
+		}
+		// pred is being inserted or deleted. Look for the one before it.
+		k := pred.key
+		key, inclusive = &k, false
+	}
+}
+
+// firstLive returns n or the first live node after it.
+func firstLive[K, V any](n *skipNode[K, V]) *skipNode[K, V] {
+	for n != nil && !n.live() {
+		n = n.next[0].Load()
+	}
+	return n
+}
+
+// unlockAll unlocks the given nodes.
+func unlockAll[K, V any](nodes []*skipNode[K, V]) {
+	for _, n := range nodes {
+		n.mu.Unlock()
+	}
+}
+
+// lockPreds locks preds[0:levels] and checks that they are still followed by succ (or succs[l] if succ is nil) and neither is being deleted.
+// Locks are taken from the bottom level up, which is in descending key order, like all other code that holds multiple locks.
+// It returns the nodes it locked, which must be unlocked by the caller.
+func lockPreds[K, V any](preds, succs *[skipListMaxLevel]*skipNode[K, V], levels int, succ *skipNode[K, V], locked []*skipNode[K, V]) ([]*skipNode[K, V], bool) {
+	for l := 0; l < levels; l++ {
+		p, s := preds[l], succ
+		if s == nil {
+			s = succs[l]
+		}
+		if len(locked) == 0 || locked[len(locked)-1] != p {
+			p.mu.Lock()
+			locked = append(locked, p)
+		}
+		if p.marked.Load() || p.next[l].Load() != s || (succ == nil && s != nil && s.marked.Load()) {
+			return locked, false
+		}
+	}
+	return locked, true
+}
+
+// put stores value for key if it isn't present. If it is present, it stores value only if overwrite is set and returns the previous value.
+func (m *ConcurrentSortedMap[K, V]) put(key K, value V, overwrite bool) (previous V, loaded bool) {
+	m.lazyInit()
+	var preds, succs [skipListMaxLevel]*skipNode[K, V]
+	var lockBuf [skipListMaxLevel]*skipNode[K, V]
+	for {
+		if l := m.find(key, &preds, &succs); l != -1 {
+			n := succs[l]
+			if n.marked.Load() {
+				// It's being deleted. Try again once it's gone.
+				runtime.Gosched()
+				continue
+			}
+			for !n.fullyLinked.Load() {
+				runtime.Gosched()
+			}
+			n.mu.Lock()
+			if n.marked.Load() {
+				n.mu.Unlock()
+				continue
+			}
+			previous = *n.value.Load()
+			if overwrite {
+				n.value.Store(&value)
+			}
+			n.mu.Unlock()
+			return previous, true
+		}
+		levels := m.randomLevel()
+		locked, valid := lockPreds(&preds, &succs, levels, nil, lockBuf[:0])
+		if !valid {
+			unlockAll(locked)
+			continue
+		}
+		n := &skipNode[K, V]{key: key, next: make([]atomic.Pointer[skipNode[K, V]], levels)}
+		n.value.Store(&value)
+		for l := 0; l < levels; l++ {
+			n.next[l].Store(succs[l])
+		}
+		for l := 0; l < levels; l++ {
+			preds[l].next[l].Store(n)
+		}
+		n.fullyLinked.Store(true)
+		unlockAll(locked)
+		m.length.Add(1)
+		return previous, false
+	}
+}
+
+// delete deletes key if cond returns true for its value (or cond is nil).
+func (m *ConcurrentSortedMap[K, V]) delete(key K, cond func(V) bool) (V, bool) {
+	m.lazyInit()
+	var preds, succs [skipListMaxLevel]*skipNode[K, V]
+	var lockBuf [skipListMaxLevel]*skipNode[K, V]
+	var victim *skipNode[K, V]
+	var zero V
+	for {
+		l := m.find(key, &preds, &succs)
+		if victim == nil {
+			if l == -1 {
+				return zero, false
+			}
+			n := succs[l]
+			if !n.fullyLinked.Load() || n.marked.Load() || len(n.next)-1 != l {
+				// The key is still being inserted (so we're before that insertion) or already being deleted.
+				return zero, false
+			}
+			n.mu.Lock()
+			if n.marked.Load() || (cond != nil && !cond(*n.value.Load())) {
+				n.mu.Unlock()
+				return zero, false
+			}
+			n.marked.Store(true)
+			victim = n
+		}
+		levels := len(victim.next)
+		locked, valid := lockPreds(&preds, &succs, levels, victim, lockBuf[:0])
+		if !valid {
+			unlockAll(locked)
+			continue
+		}
+		for l := levels - 1; l >= 0; l-- {
+			preds[l].next[l].Store(victim.next[l].Load())
+		}
+		v := *victim.value.Load()
+		victim.mu.Unlock()
+		unlockAll(locked)
+		m.length.Add(-1)
+		return v, true
+	}
+}
+
+// Load returns the value stored in the map for a key. The ok result indicates whether value was found in the map.
+func (m *ConcurrentSortedMap[K, V]) Load(key K) (V, bool) {
+	if n := m.search(key); n != nil && n.live() {
+		return *n.value.Load(), true
+	}
+	var zero V
+	return zero, false
+}
+
+// LoadOrZero returns the value stored in the map for a key, or zero if no value is present. This is the same as Load() but ignoring the second result.
+func (m *ConcurrentSortedMap[K, V]) LoadOrZero(key K) V {
+	v, _ := m.Load(key)
+	return v
+}
+
+// Store sets the value for a key.
+func (m *ConcurrentSortedMap[K, V]) Store(key K, value V) {
+	m.put(key, value, true)
+}
+
+// Swap swaps the value for a key and returns the previous value if any. The loaded result reports whether the key was present.
+func (m *ConcurrentSortedMap[K, V]) Swap(key K, value V) (previous V, loaded bool) {
+	return m.put(key, value, true)
+}
+
+// LoadOrStore returns the existing value for the key if present. Otherwise, it stores and returns the given value. The loaded result is true if the value was loaded, false if stored.
+func (m *ConcurrentSortedMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	if v, ok := m.Load(key); ok {
+		return v, true
+	}
+	if v, loaded := m.put(key, value, false); loaded {
+		return v, true
+	}
+	return value, false
+}
+
+// Delete deletes the value for a key.
+func (m *ConcurrentSortedMap[K, V]) Delete(key K) {
+	m.delete(key, nil)
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if any. The second result reports whether the key was present.
+func (m *ConcurrentSortedMap[K, V]) LoadAndDelete(key K) (V, bool) {
+	return m.delete(key, nil)
+}
+
+// CompareAndDelete deletes the entry for key if its value is equal to old.
+//
+// This is a function rather than a method because Go 1.18 doesn't allow restricting a method's type parameters more than the base type (yet?).
+func ConcurrentSortedMapCompareAndDelete[K constraints.Ordered, V comparable](m *ConcurrentSortedMap[K, V], key K, old V) (deleted bool) {
+	_, deleted = m.delete(key, func(v V) bool {
+		return v == old
+	})
+	return deleted
+}
+
+// CompareAndSwap swaps the old and new values for key if the value stored in the map is equal to old. The old value must be of a comparable type.
+//
+// This is a function rather than a method because Go 1.18 doesn't allow restricting a method's type parameters more than the base type (yet?).
+func ConcurrentSortedMapCompareAndSwap[K constraints.Ordered, V comparable](m *ConcurrentSortedMap[K, V], key K, old, new V) bool {
+	n := m.search(key)
+	if n == nil || !n.fullyLinked.Load() {
+		return false
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.marked.Load() || *n.value.Load() != old {
+		return false
+	}
+	n.value.Store(&new)
+	return true
+}
+
+// Len returns the number of elements in the map.
+func (m *ConcurrentSortedMap[K, V]) Len() int {
+	return int(m.length.Load())
+}
+
+// Min returns the smallest key and its value. The ok result is false if the map is empty.
+func (m *ConcurrentSortedMap[K, V]) Min() (key K, value V, ok bool) {
+	m.lazyInit()
+	if n := firstLive(m.head.next[0].Load()); n != nil {
+		return n.key, *n.value.Load(), true
+	}
+	return key, value, false
+}
+
+// Max returns the largest key and its value. The ok result is false if the map is empty.
+func (m *ConcurrentSortedMap[K, V]) Max() (key K, value V, ok bool) {
+	if n := m.before(nil, false); n != nil {
+		return n.key, *n.value.Load(), true
+	}
+	return key, value, false
+}
+
+// Floor returns the greatest key less than or equal to the given key, and its value. The ok result is false if there is no such key.
+func (m *ConcurrentSortedMap[K, V]) Floor(key K) (K, V, bool) {
+	if n := m.before(&key, true); n != nil {
+		return n.key, *n.value.Load(), true
+	}
+	var k K
+	var v V
+	return k, v, false
+}
+
+// Ceiling returns the least key greater than or equal to the given key, and its value. The ok result is false if there is no such key.
+func (m *ConcurrentSortedMap[K, V]) Ceiling(key K) (K, V, bool) {
+	if n := firstLive(m.seek(key)); n != nil {
+		return n.key, *n.value.Load(), true
+	}
+	var k K
+	var v V
+	return k, v, false
+}
+
+// Range calls f sequentially for each key and value present in the map, in ascending order. If f returns false, range stops the iteration.
+//
+// Range does not block other methods on the receiver; even f itself may call any method on m. See ConcurrentSortedMap for the consistency guarantees.
+func (m *ConcurrentSortedMap[K, V]) Range(f func(key K, value V) bool) {
+	m.lazyInit()
+	m.ascend(m.head.next[0].Load(), nil, f)
+}
+
+// RangeBetween is like Range, but only visits keys greater than or equal to from and less than to.
+func (m *ConcurrentSortedMap[K, V]) RangeBetween(from, to K, f func(key K, value V) bool) {
+	m.ascend(m.seek(from), &to, f)
+}
+
+func (m *ConcurrentSortedMap[K, V]) ascend(n *skipNode[K, V], to *K, f func(key K, value V) bool) {
+	// Deleted nodes keep pointing at their successor, so we can always continue from n.
+	for ; n != nil; n = n.next[0].Load() {
+		if to != nil && compareOrdered(n.key, *to) >= 0 {
+			return
+		}
+		if !n.live() {
+			continue
+		}
+		if !f(n.key, *n.value.Load()) {
+			return
+		}
+	}
+}
+
+// RangeBackward is like Range, but iterates in descending order.
+// The skip list only links forward, so every step is a lookup of O(log n) and the whole iteration O(n log n).
+func (m *ConcurrentSortedMap[K, V]) RangeBackward(f func(key K, value V) bool) {
+	m.descend(m.before(nil, false), nil, f)
+}
+
+// RangeBetweenBackward is like RangeBetween, but iterates in descending order. It has the same cost as RangeBackward.
+func (m *ConcurrentSortedMap[K, V]) RangeBetweenBackward(from, to K, f func(key K, value V) bool) {
+	m.descend(m.before(&to, false), &from, f)
+}
+
+func (m *ConcurrentSortedMap[K, V]) descend(n *skipNode[K, V], from *K, f func(key K, value V) bool) {
+	for n != nil {
+		if from != nil && compareOrdered(n.key, *from) < 0 {
+			return
+		}
+		if !f(n.key, *n.value.Load()) {
+			return
+		}
+		k := n.key
+		n = m.before(&k, false)
+	}
+}
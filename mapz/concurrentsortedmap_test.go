@@ -0,0 +1,277 @@
+//go:build go1.19
+
+package mapz
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"golang.org/x/exp/constraints"
+)
+
+// checkSkipList verifies the invariants of the skip list and returns its keys in order.
+func checkSkipList[K constraints.Ordered, V any](t *testing.T, m *ConcurrentSortedMap[K, V]) []K {
+	t.Helper()
+	m.lazyInit()
+	var keys []K
+	for n := m.head.next[0].Load(); n != nil; n = n.next[0].Load() {
+		if !n.live() {
+			t.Fatalf("node %v is not live", n.key)
+		}
+		if len(keys) > 0 && compareOrdered(keys[len(keys)-1], n.key) >= 0 {
+			t.Fatalf("keys out of order: %v before %v", keys[len(keys)-1], n.key)
+		}
+		keys = append(keys, n.key)
+	}
+	// Every level must be a sublist of the level below.
+	for l := 1; l < skipListMaxLevel; l++ {
+		below := m.head.next[l-1].Load()
+		for n := m.head.next[l].Load(); n != nil; n = n.next[l].Load() {
+			for below != n {
+				if below == nil {
+					t.Fatalf("node %v on level %d is missing from level %d", n.key, l, l-1)
+				}
+				below = below.next[l-1].Load()
+			}
+		}
+	}
+	if m.Len() != len(keys) {
+		t.Fatalf("Len() = %d, but the list has %d keys", m.Len(), len(keys))
+	}
+	return keys
+}
+
+func TestConcurrentSortedMapRandomized(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	var m ConcurrentSortedMap[int, int]
+	want := map[int]int{}
+	for round := 0; round < 20000; round++ {
+		k := rnd.Intn(2000)
+		switch op := rnd.Intn(10); {
+		case op < 5:
+			p, loaded := m.Swap(k, round)
+			wp, wloaded := want[k]
+			if p != wp || loaded != wloaded {
+				t.Fatalf("Swap(%d) = %d, %v; want %d, %v", k, p, loaded, wp, wloaded)
+			}
+			want[k] = round
+		case op < 8:
+			v, ok := m.LoadAndDelete(k)
+			wv, wok := want[k]
+			if v != wv || ok != wok {
+				t.Fatalf("LoadAndDelete(%d) = %d, %v; want %d, %v", k, v, ok, wv, wok)
+			}
+			delete(want, k)
+		default:
+			v, loaded := m.LoadOrStore(k, round)
+			if wv, ok := want[k]; ok {
+				if !loaded || v != wv {
+					t.Fatalf("LoadOrStore(%d) = %d, %v; want %d, true", k, v, loaded, wv)
+				}
+			} else {
+				want[k] = round
+			}
+		}
+		if round%1000 != 0 {
+			continue
+		}
+		keys := checkSkipList(t, &m)
+		if len(keys) != len(want) {
+			t.Fatalf("map has %d keys; want %d", len(keys), len(want))
+		}
+		for _, k := range keys {
+			if v, ok := m.Load(k); !ok || v != want[k] {
+				t.Fatalf("Load(%d) = %d, %v; want %d, true", k, v, ok, want[k])
+			}
+		}
+		for q := -1; q <= 2000; q += 7 {
+			i := sort.SearchInts(keys, q)
+			if fk, _, ok := m.Ceiling(q); ok != (i < len(keys)) || (ok && fk != keys[i]) || (!ok && fk != 0) {
+				t.Fatalf("Ceiling(%d) = %d, %v", q, fk, ok)
+			}
+			j := sort.SearchInts(keys, q+1) - 1
+			if fk, _, ok := m.Floor(q); ok != (j >= 0) || (ok && fk != keys[j]) || (!ok && fk != 0) {
+				t.Fatalf("Floor(%d) = %d, %v", q, fk, ok)
+			}
+		}
+	}
+	for k := range want {
+		m.Delete(k)
+	}
+	if keys := checkSkipList(t, &m); len(keys) != 0 {
+		t.Errorf("map isn't empty after deleting everything")
+	}
+}
+
+func TestConcurrentSortedMapRange(t *testing.T) {
+	var m ConcurrentSortedMap[int, string]
+	if _, _, ok := m.Min(); ok {
+		t.Errorf("Min() on an empty map succeeded")
+	}
+	if _, _, ok := m.Max(); ok {
+		t.Errorf("Max() on an empty map succeeded")
+	}
+	for i := 0; i < 1000; i += 2 {
+		m.Store(i, "x")
+	}
+	if k, _, _ := m.Min(); k != 0 {
+		t.Errorf("Min() = %d; want 0", k)
+	}
+	if k, _, _ := m.Max(); k != 998 {
+		t.Errorf("Max() = %d; want 998", k)
+	}
+	keys := func(rng func(f func(int, string) bool)) []int {
+		var ret []int
+		rng(func(k int, v string) bool {
+			ret = append(ret, k)
+			return true
+		})
+		return ret
+	}
+	if got := keys(m.Range); len(got) != 500 || !sort.IntsAreSorted(got) {
+		t.Errorf("Range visited %d keys, sorted: %v", len(got), sort.IntsAreSorted(got))
+	}
+	if got := keys(m.RangeBackward); len(got) != 500 || got[0] != 998 || got[499] != 0 {
+		t.Errorf("RangeBackward visited %d keys from %d to %d", len(got), got[0], got[len(got)-1])
+	}
+	between := func(from, to int) func(f func(int, string) bool) {
+		return func(f func(int, string) bool) { m.RangeBetween(from, to, f) }
+	}
+	betweenBackward := func(from, to int) func(f func(int, string) bool) {
+		return func(f func(int, string) bool) { m.RangeBetweenBackward(from, to, f) }
+	}
+	if got, want := keys(between(11, 20)), []int{12, 14, 16, 18}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RangeBetween(11, 20) = %v; want %v", got, want)
+	}
+	if got, want := keys(betweenBackward(12, 18)), []int{16, 14, 12}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RangeBetweenBackward(12, 18) = %v; want %v", got, want)
+	}
+	if got := keys(between(20, 10)); len(got) != 0 {
+		t.Errorf("RangeBetween(20, 10) = %v; want nothing", got)
+	}
+
+	// Modify the map while iterating. Deleted keys aren't visited. The iterator carries on from the deleted node it's at, which still points at the node that followed it when it was deleted, so the keys inserted right ahead of it are skipped.
+	var visited []int
+	m.RangeBetween(100, 110, func(k int, v string) bool {
+		visited = append(visited, k)
+		m.Delete(k)
+		m.Delete(k + 2)
+		m.Store(k-1, "behind")
+		m.Store(k+3, "ahead")
+		return true
+	})
+	if want := []int{100, 104, 108}; !reflect.DeepEqual(visited, want) {
+		t.Errorf("RangeBetween with modifications visited %v; want %v", visited, want)
+	}
+	checkSkipList(t, &m)
+}
+
+func TestConcurrentSortedMapCompareAndSwap(t *testing.T) {
+	var m ConcurrentSortedMap[string, int]
+	if ConcurrentSortedMapCompareAndSwap(&m, "a", 0, 1) {
+		t.Errorf("CompareAndSwap on a missing key succeeded")
+	}
+	m.Store("a", 1)
+	if ConcurrentSortedMapCompareAndSwap(&m, "a", 2, 3) {
+		t.Errorf("CompareAndSwap(a, 2, 3) succeeded; want failure")
+	}
+	if !ConcurrentSortedMapCompareAndSwap(&m, "a", 1, 3) {
+		t.Errorf("CompareAndSwap(a, 1, 3) failed; want success")
+	}
+	if v := m.LoadOrZero("a"); v != 3 {
+		t.Errorf("LoadOrZero(a) = %d; want 3", v)
+	}
+	if ConcurrentSortedMapCompareAndDelete(&m, "a", 1) {
+		t.Errorf("CompareAndDelete(a, 1) succeeded; want failure")
+	}
+	if !ConcurrentSortedMapCompareAndDelete(&m, "a", 3) {
+		t.Errorf("CompareAndDelete(a, 3) failed; want success")
+	}
+	if m.Len() != 0 {
+		t.Errorf("Len() = %d; want 0", m.Len())
+	}
+}
+
+// TestConcurrentSortedMapScans checks the guarantees of the lock-free reads while writers constantly insert and delete the odd keys. The even keys are never touched.
+func TestConcurrentSortedMapScans(t *testing.T) {
+	const n = 2000
+	var m ConcurrentSortedMap[int, int]
+	for i := 0; i < n; i += 2 {
+		m.Store(i, i)
+	}
+	stop := make(chan struct{})
+	var writers sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		writers.Add(1)
+		go func(w int) {
+			defer writers.Done()
+			rnd := rand.New(rand.NewSource(int64(w)))
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				k := 2*rnd.Intn(n/2) + 1
+				if rnd.Intn(2) == 0 {
+					m.Store(k, k)
+				} else {
+					m.Delete(k)
+				}
+			}
+		}(w)
+	}
+	var readers sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		readers.Add(1)
+		go func(r int) {
+			defer readers.Done()
+			rnd := rand.New(rand.NewSource(int64(100 + r)))
+			for round := 0; round < 50; round++ {
+				evens := 0
+				prev := -1
+				m.Range(func(k, v int) bool {
+					if k <= prev {
+						t.Errorf("Range visited %d after %d", k, prev)
+					}
+					if k != v {
+						t.Errorf("Range visited %d with value %d", k, v)
+					}
+					if k%2 == 0 {
+						evens++
+					}
+					prev = k
+					return true
+				})
+				if evens != n/2 {
+					t.Errorf("Range visited %d even keys; want %d", evens, n/2)
+				}
+				prev = n
+				m.RangeBetweenBackward(n/4, n/2, func(k, v int) bool {
+					if k >= prev {
+						t.Errorf("RangeBetweenBackward visited %d after %d", k, prev)
+					}
+					prev = k
+					return true
+				})
+				if prev != n/4 {
+					t.Errorf("RangeBetweenBackward ended at %d; want %d", prev, n/4)
+				}
+				q := 2*rnd.Intn(n/2-1) + 1
+				if k, _, ok := m.Floor(q); !ok || (k != q && k != q-1) {
+					t.Errorf("Floor(%d) = %d, %v", q, k, ok)
+				}
+				if k, _, ok := m.Ceiling(q); !ok || (k != q && k != q+1) {
+					t.Errorf("Ceiling(%d) = %d, %v", q, k, ok)
+				}
+			}
+		}(r)
+	}
+	readers.Wait()
+	close(stop)
+	writers.Wait()
+	checkSkipList(t, &m)
+}
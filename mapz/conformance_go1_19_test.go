@@ -0,0 +1,34 @@
+//go:build go1.19
+
+package mapz_test
+
+import (
+	"testing"
+
+	"github.com/Jille/genericz/mapz"
+	"github.com/Jille/genericz/mapz/mapztest"
+)
+
+func TestAppendMapConformance(t *testing.T) {
+	newMap := func() mapz.AppendOnlyMap[string, int] {
+		return &mapz.AppendMap[string, int]{}
+	}
+	mapztest.TestAppendOnlyMap(t, newMap, stringKey, intValue)
+	mapztest.TestConcurrentAppendOnlyMap(t, newMap, stringKey, intValue)
+}
+
+func TestCOWMapConformance(t *testing.T) {
+	newMap := func() mapz.Map[string, int] {
+		return &mapz.COWMap[string, int]{}
+	}
+	mapztest.TestMap(t, newMap, stringKey, intValue)
+	mapztest.TestConcurrentMap(t, newMap, stringKey, intValue)
+}
+
+func TestConcurrentSortedMapConformance(t *testing.T) {
+	newMap := func() mapz.Map[string, int] {
+		return &mapz.ConcurrentSortedMap[string, int]{}
+	}
+	mapztest.TestMap(t, newMap, stringKey, intValue)
+	mapztest.TestConcurrentMap(t, newMap, stringKey, intValue)
+}
@@ -0,0 +1,70 @@
+package mapz_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/Jille/genericz/mapz"
+	"github.com/Jille/genericz/mapz/mapztest"
+)
+
+func TestConformance(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		new        func() mapz.Map[string, int]
+		concurrent bool
+	}{
+		{"MutexMap", func() mapz.Map[string, int] { return &mapz.MutexMap[string, int]{} }, true},
+		{"RWMutexMap", func() mapz.Map[string, int] { return &mapz.RWMutexMap[string, int]{} }, true},
+		{"SyncMap", func() mapz.Map[string, int] { return &mapz.SyncMap[string, int]{} }, true},
+		{"UnsyncedMap", func() mapz.Map[string, int] { return &mapz.UnsyncedMap[string, int]{} }, false},
+		{"ShardedMap", func() mapz.Map[string, int] { return &mapz.ShardedMap[string, int]{} }, true},
+		{"LRUMap", func() mapz.Map[string, int] { return mapz.NewLRUMap[string, int](1000) }, true},
+		{"LinkedMap", func() mapz.Map[string, int] { return &mapz.LinkedMap[string, int]{} }, false},
+		{"SortedMap", func() mapz.Map[string, int] { return &mapz.SortedMap[string, int]{} }, false},
+		{"WatchableMap", func() mapz.Map[string, int] { return &mapz.WatchableMap[string, int]{} }, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mapztest.TestMap(t, tc.new, stringKey, intValue)
+			if tc.concurrent {
+				mapztest.TestConcurrentMap(t, tc.new, stringKey, intValue)
+			}
+		})
+	}
+}
+
+func stringKey(i int) string {
+	return strconv.Itoa(i)
+}
+
+func intValue(i int) int {
+	return i + 1
+}
+
+type conformanceKey struct {
+	s string
+	n int
+}
+
+func TestConformanceOtherTypes(t *testing.T) {
+	key := func(i int) conformanceKey {
+		return conformanceKey{strconv.Itoa(i / 2), i % 2}
+	}
+	value := func(i int) []string {
+		return []string{strconv.Itoa(i)}
+	}
+	t.Run("MutexMap", func(t *testing.T) {
+		newMap := func() mapz.Map[conformanceKey, []string] { return &mapz.MutexMap[conformanceKey, []string]{} }
+		mapztest.TestMap(t, newMap, key, value)
+		mapztest.TestConcurrentMap(t, newMap, key, value)
+	})
+	t.Run("ShardedMap", func(t *testing.T) {
+		newMap := func() mapz.Map[conformanceKey, []string] { return &mapz.ShardedMap[conformanceKey, []string]{} }
+		mapztest.TestMap(t, newMap, key, value)
+		mapztest.TestConcurrentMap(t, newMap, key, value)
+	})
+	t.Run("SortedMap", func(t *testing.T) {
+		newMap := func() mapz.Map[float64, *int] { return &mapz.SortedMap[float64, *int]{} }
+		mapztest.TestMap(t, newMap, func(i int) float64 { return float64(i) / 2 }, func(i int) *int { return &i })
+	})
+}
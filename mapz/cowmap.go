@@ -0,0 +1,235 @@
+//go:build go1.19
+
+package mapz
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// COWMap is a concurrency-safe copy-on-write map. Its interface closely resembles MutexMap.
+// Reads are a single atomic load of an immutable map and never block. Every write copies the entire map under a mutex and atomically publishes the copy.
+//
+// The cost model is simple: reads are as cheap as reading a regular map, writes are O(n) in the size of the map and are serialized.
+// Use Update, StoreAll or DeleteMany to pay for only one copy when making several changes.
+// This makes COWMap ideal for small to medium sized maps that are read very frequently and rarely updated, like routing tables or configuration.
+// If values are only ever added, AppendMap avoids most of the copying.
+//
+// The zero value is valid.
+type COWMap[K comparable, V any] struct {
+	p atomic.Pointer[map[K]V]
+	l sync.Mutex
+}
+
+var _ Map[string, int] = &COWMap[string, int]{}
+
+func (m *COWMap[K, V]) load() map[K]V {
+	if p := m.p.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// copyLocked returns a copy of the current map with room for extra more entries. m.l must be held.
+func (m *COWMap[K, V]) copyLocked(extra int) map[K]V {
+	cur := m.load()
+	n := make(map[K]V, len(cur)+extra)
+	for k, v := range cur {
+		n[k] = v
+	}
+	return n
+}
+
+func (m *COWMap[K, V]) publish(n map[K]V) {
+	m.p.Store(&n)
+}
+
+// Load returns the value stored in the map for a key. The ok result indicates whether value was found in the map.
+func (m *COWMap[K, V]) Load(key K) (V, bool) {
+	v, ok := m.load()[key]
+	return v, ok
+}
+
+// LoadOrZero returns the value stored in the map for a key, or zero if no value is present. This is the same as Load() but ignoring the second result.
+func (m *COWMap[K, V]) LoadOrZero(key K) V {
+	return m.load()[key]
+}
+
+// Snapshot returns the current contents of the map. This doesn't copy anything: later writes to m don't affect the returned map.
+// The returned map must not be modified. It may be nil if the map is empty.
+func (m *COWMap[K, V]) Snapshot() map[K]V {
+	return m.load()
+}
+
+// Store sets the value for a key. This copies the map.
+func (m *COWMap[K, V]) Store(key K, value V) {
+	m.Swap(key, value)
+}
+
+// Swap swaps the value for a key and returns the previous value if any. The loaded result reports whether the key was present. This copies the map.
+func (m *COWMap[K, V]) Swap(key K, value V) (previous V, loaded bool) {
+	m.l.Lock()
+	defer m.l.Unlock()
+	previous, loaded = m.load()[key]
+	n := m.copyLocked(1)
+	n[key] = value
+	m.publish(n)
+	return previous, loaded
+}
+
+// Delete deletes the value for a key. This copies the map if the key was present.
+func (m *COWMap[K, V]) Delete(key K) {
+	m.LoadAndDelete(key)
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if any. The second result reports whether the key was present. This copies the map if the key was present.
+func (m *COWMap[K, V]) LoadAndDelete(key K) (V, bool) {
+	m.l.Lock()
+	defer m.l.Unlock()
+	v, ok := m.load()[key]
+	if !ok {
+		return v, false
+	}
+	n := m.copyLocked(0)
+	delete(n, key)
+	m.publish(n)
+	return v, true
+}
+
+// LoadOrStore returns the existing value for the key if present. Otherwise, it stores and returns the given value. The loaded result is true if the value was loaded, false if stored.
+// Only storing copies the map; loading an existing value doesn't pick up the mutex.
+func (m *COWMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	if v, ok := m.load()[key]; ok {
+		return v, true
+	}
+	m.l.Lock()
+	defer m.l.Unlock()
+	if v, ok := m.load()[key]; ok {
+		return v, true
+	}
+	n := m.copyLocked(1)
+	n[key] = value
+	m.publish(n)
+	return value, false
+}
+
+// CompareAndDelete deletes the entry for key if its value is equal to old. This copies the map if the entry was deleted.
+//
+// This is a function rather than a method because Go 1.18 doesn't allow restricting a method's type parameters more than the base type (yet?).
+func COWMapCompareAndDelete[K, V comparable](m *COWMap[K, V], key K, old V) (deleted bool) {
+	m.l.Lock()
+	defer m.l.Unlock()
+	if v, ok := m.load()[key]; !ok || v != old {
+		return false
+	}
+	n := m.copyLocked(0)
+	delete(n, key)
+	m.publish(n)
+	return true
+}
+
+// CompareAndSwap swaps the old and new values for key if the value stored in the map is equal to old. The old value must be of a comparable type. This copies the map if the values were swapped.
+//
+// This is a function rather than a method because Go 1.18 doesn't allow restricting a method's type parameters more than the base type (yet?).
+func COWMapCompareAndSwap[K, V comparable](m *COWMap[K, V], key K, old, new V) bool {
+	m.l.Lock()
+	defer m.l.Unlock()
+	if m.load()[key] != old {
+		return false
+	}
+	n := m.copyLocked(0)
+	n[key] = new
+	m.publish(n)
+	return true
+}
+
+// Update calls f with a private copy of the map and atomically publishes the result after f returns. This copies the map once, no matter how many changes f makes.
+// Readers see either none or all of the changes. If f panics, nothing is published.
+//
+// f is called with the mutex held, so it must not call any writing methods on m. f must not retain the map after it returns.
+func (m *COWMap[K, V]) Update(f func(m map[K]V)) {
+	m.l.Lock()
+	defer m.l.Unlock()
+	n := m.copyLocked(0)
+	f(n)
+	m.publish(n)
+}
+
+// StoreAll sets the values for all keys in entries. The map is copied only once and readers see either none or all of the new values.
+func (m *COWMap[K, V]) StoreAll(entries map[K]V) {
+	if len(entries) == 0 {
+		return
+	}
+	m.l.Lock()
+	defer m.l.Unlock()
+	n := m.copyLocked(len(entries))
+	for k, v := range entries {
+		n[k] = v
+	}
+	m.publish(n)
+}
+
+// LoadMany returns the values stored in the map for the given keys. Keys that aren't present are omitted from the result. All values come from the same snapshot.
+func (m *COWMap[K, V]) LoadMany(keys ...K) map[K]V {
+	cur := m.load()
+	ret := make(map[K]V, len(keys))
+	for _, k := range keys {
+		if v, ok := cur[k]; ok {
+			ret[k] = v
+		}
+	}
+	return ret
+}
+
+// DeleteMany deletes the values for the given keys. The map is copied at most once.
+func (m *COWMap[K, V]) DeleteMany(keys ...K) {
+	m.l.Lock()
+	defer m.l.Unlock()
+	cur := m.load()
+	var n map[K]V
+	for _, k := range keys {
+		if _, ok := cur[k]; !ok {
+			continue
+		}
+		if n == nil {
+			n = m.copyLocked(0)
+		}
+		delete(n, k)
+	}
+	if n != nil {
+		m.publish(n)
+	}
+}
+
+// LoadAndDeleteAll atomically clears the map and returns the entries it had. The returned map is a copy, because concurrent readers might still be using the old one.
+func (m *COWMap[K, V]) LoadAndDeleteAll() map[K]V {
+	m.l.Lock()
+	defer m.l.Unlock()
+	p := m.p.Swap(nil)
+	if p == nil {
+		return map[K]V{}
+	}
+	ret := make(map[K]V, len(*p))
+	for k, v := range *p {
+		ret[k] = v
+	}
+	return ret
+}
+
+// Range calls f sequentially for each key and value present in the map. If f returns false, range stops the iteration.
+//
+// Range does not block other methods on the receiver; even f itself may call any method on m.
+//
+// Range iterates over a consistent snapshot of the map, taken before the first callback.
+func (m *COWMap[K, V]) Range(f func(key K, value V) bool) {
+	for k, v := range m.load() {
+		if !f(k, v) {
+			return
+		}
+	}
+}
+
+// Len returns the number of elements in the map.
+func (m *COWMap[K, V]) Len() int {
+	return len(m.load())
+}
@@ -0,0 +1,115 @@
+//go:build go1.19
+
+package mapz
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestCOWMapSnapshot(t *testing.T) {
+	var m COWMap[string, int]
+	if s := m.Snapshot(); len(s) != 0 {
+		t.Errorf("Snapshot() of empty map = %v; want empty", s)
+	}
+	m.Store("a", 1)
+	s := m.Snapshot()
+	m.Store("a", 2)
+	m.Store("b", 3)
+	m.Delete("a")
+	if len(s) != 1 || s["a"] != 1 {
+		t.Errorf("Snapshot was affected by later writes: %v", s)
+	}
+	if got := m.Snapshot(); len(got) != 1 || got["b"] != 3 {
+		t.Errorf("Snapshot() = %v; want map[b:3]", got)
+	}
+}
+
+func TestCOWMapCompareAndSwap(t *testing.T) {
+	var m COWMap[string, int]
+	m.Store("a", 1)
+	if COWMapCompareAndSwap(&m, "a", 2, 3) {
+		t.Errorf("CompareAndSwap(a, 2, 3) succeeded; want failure")
+	}
+	if !COWMapCompareAndSwap(&m, "a", 1, 3) {
+		t.Errorf("CompareAndSwap(a, 1, 3) failed; want success")
+	}
+	if v := m.LoadOrZero("a"); v != 3 {
+		t.Errorf("LoadOrZero(a) = %d; want 3", v)
+	}
+	if COWMapCompareAndDelete(&m, "a", 1) {
+		t.Errorf("CompareAndDelete(a, 1) succeeded; want failure")
+	}
+	if !COWMapCompareAndDelete(&m, "a", 3) {
+		t.Errorf("CompareAndDelete(a, 3) failed; want success")
+	}
+	if m.Len() != 0 {
+		t.Errorf("Len() = %d; want 0", m.Len())
+	}
+}
+
+func TestCOWMapUpdate(t *testing.T) {
+	var m COWMap[string, int]
+	m.StoreAll(map[string]int{"a": 1, "b": 2})
+	m.Update(func(mm map[string]int) {
+		mm["c"] = mm["a"] + mm["b"]
+		delete(mm, "a")
+	})
+	if got := m.Snapshot(); len(got) != 2 || got["b"] != 2 || got["c"] != 3 {
+		t.Errorf("Snapshot() = %v; want map[b:2 c:3]", got)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Update didn't propagate the panic")
+			}
+		}()
+		m.Update(func(mm map[string]int) {
+			mm["d"] = 4
+			panic("boom")
+		})
+	}()
+	if _, ok := m.Load("d"); ok {
+		t.Errorf("Update published changes of a panicking function")
+	}
+	// The mutex should have been released.
+	m.Store("e", 5)
+}
+
+func TestCOWMapConcurrentUpdate(t *testing.T) {
+	// Writers move one unit between a and b in a single Update. Readers should never see a partial update.
+	var m COWMap[string, int]
+	m.StoreAll(map[string]int{"a": 100, "b": 0})
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				s := m.Snapshot()
+				if s["a"]+s["b"] != 100 {
+					t.Errorf("Snapshot() = %v; want a+b = 100", s)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 1000; i++ {
+		m.Update(func(mm map[string]int) {
+			mm["a"]--
+			mm["b"]++
+		})
+	}
+	close(stop)
+	wg.Wait()
+	if v := m.LoadOrZero("b"); v != 1000 {
+		t.Errorf("LoadOrZero(b) = %d; want 1000", v)
+	}
+}
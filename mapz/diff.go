@@ -0,0 +1,108 @@
+package mapz
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/exp/constraints"
+	"golang.org/x/exp/slices"
+)
+
+// Difference describes how one map differs from another. See Diff.
+type Difference[K comparable, V any] struct {
+	// Added has the entries that are only in the new map.
+	Added map[K]V
+	// Removed has the entries that are only in the old map, with their old values.
+	Removed map[K]V
+	// Changed has the keys that are in both maps with a different value.
+	Changed map[K]Change[V]
+}
+
+// Change is the old and new value of a key.
+type Change[V any] struct {
+	Old V
+	New V
+}
+
+// Diff returns the differences between old and new. Values are compared with ==.
+func Diff[M ~map[K]V, K, V comparable](old, new M) Difference[K, V] {
+	return DiffFunc(old, new, func(a, b V) bool {
+		return a == b
+	})
+}
+
+// DiffFunc is like Diff, but uses eq to compare values.
+func DiffFunc[M ~map[K]V, K comparable, V any](old, new M, eq func(a, b V) bool) Difference[K, V] {
+	d := Difference[K, V]{
+		Added:   map[K]V{},
+		Removed: map[K]V{},
+		Changed: map[K]Change[V]{},
+	}
+	for k, o := range old {
+		n, ok := new[k]
+		if !ok {
+			d.Removed[k] = o
+		} else if !eq(o, n) {
+			d.Changed[k] = Change[V]{o, n}
+		}
+	}
+	for k, n := range new {
+		if _, ok := old[k]; !ok {
+			d.Added[k] = n
+		}
+	}
+	return d
+}
+
+// Empty returns whether there are no differences.
+func (d Difference[K, V]) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Apply modifies m by adding, removing and changing the entries in d. Applying Diff(old, new) to old turns it into new.
+// Apply doesn't check that m matches the old map: removed keys are deleted and added and changed keys are set, regardless of their current value.
+func (d Difference[K, V]) Apply(m map[K]V) {
+	for k := range d.Removed {
+		delete(m, k)
+	}
+	for k, v := range d.Added {
+		m[k] = v
+	}
+	for k, c := range d.Changed {
+		m[k] = c.New
+	}
+}
+
+// FormatDiff returns a human readable description of d with one line per key, sorted by key.
+// Added keys are prefixed with "+", removed keys with "-" and changed keys with "~", followed by the key and the value(s) formatted with %v.
+func FormatDiff[K constraints.Ordered, V any](d Difference[K, V]) string {
+	keys := make([]K, 0, len(d.Added)+len(d.Removed)+len(d.Changed))
+	for k := range d.Added {
+		keys = append(keys, k)
+	}
+	for k := range d.Removed {
+		keys = append(keys, k)
+	}
+	for k := range d.Changed {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b K) bool {
+		return compareOrdered(a, b) < 0
+	})
+	var sb strings.Builder
+	for i, k := range keys {
+		if i > 0 && compareOrdered(keys[i-1], k) == 0 {
+			continue
+		}
+		if v, ok := d.Added[k]; ok {
+			fmt.Fprintf(&sb, "+ %v: %v\n", k, v)
+		}
+		if v, ok := d.Removed[k]; ok {
+			fmt.Fprintf(&sb, "- %v: %v\n", k, v)
+		}
+		if c, ok := d.Changed[k]; ok {
+			fmt.Fprintf(&sb, "~ %v: %v -> %v\n", k, c.Old, c.New)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,48 @@
+package mapz
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	old := map[string]int{"a": 1, "b": 2, "c": 3}
+	new := map[string]int{"b": 2, "c": 4, "d": 5}
+	d := Diff(old, new)
+	want := Difference[string, int]{
+		Added:   map[string]int{"d": 5},
+		Removed: map[string]int{"a": 1},
+		Changed: map[string]Change[int]{"c": {3, 4}},
+	}
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("Diff() = %+v; want %+v", d, want)
+	}
+	if d.Empty() {
+		t.Errorf("Empty() = true; want false")
+	}
+	if got, want := FormatDiff(d), "- a: 1\n~ c: 3 -> 4\n+ d: 5\n"; got != want {
+		t.Errorf("FormatDiff() = %q; want %q", got, want)
+	}
+
+	d.Apply(old)
+	if !reflect.DeepEqual(old, new) {
+		t.Errorf("Apply() turned old into %v; want %v", old, new)
+	}
+	if d := Diff(old, new); !d.Empty() || FormatDiff(d) != "" {
+		t.Errorf("Diff() of equal maps = %+v; want no differences", d)
+	}
+}
+
+func TestDiffFunc(t *testing.T) {
+	old := map[int][]string{1: {"x"}, 2: {"y"}}
+	new := map[int][]string{1: {"x"}, 2: {"y", "z"}}
+	d := DiffFunc(old, new, func(a, b []string) bool {
+		return reflect.DeepEqual(a, b)
+	})
+	if len(d.Added) != 0 || len(d.Removed) != 0 || len(d.Changed) != 1 {
+		t.Errorf("DiffFunc() = %+v; want only key 2 changed", d)
+	}
+	if got, want := FormatDiff(d), "~ 2: [y] -> [y z]\n"; got != want {
+		t.Errorf("FormatDiff() = %q; want %q", got, want)
+	}
+}
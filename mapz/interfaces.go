@@ -0,0 +1,42 @@
+package mapz
+
+// Loader is the read-only part of the map types in this package.
+type Loader[K comparable, V any] interface {
+	// Load returns the value stored in the map for a key. The ok result indicates whether value was found in the map.
+	Load(key K) (V, bool)
+	// LoadOrZero returns the value stored in the map for a key, or zero if no value is present.
+	LoadOrZero(key K) V
+	// Range calls f sequentially for each key and value present in the map. If f returns false, range stops the iteration.
+	Range(f func(key K, value V) bool)
+}
+
+// AppendOnlyMap is a map to which values can be added but never changed. It is implemented by AppendMap and by all types that implement Map.
+type AppendOnlyMap[K comparable, V any] interface {
+	Loader[K, V]
+	// LoadOrStore returns the existing value for the key if present. Otherwise, it stores and returns the given value. The loaded result is true if the value was loaded, false if stored.
+	LoadOrStore(key K, value V) (actual V, loaded bool)
+}
+
+// Map is the interface shared by MutexMap, RWMutexMap, SyncMap, UnsyncedMap, ShardedMap and LRUMap. It allows switching between implementations.
+// Note that UnsyncedMap implements Map, but isn't safe for concurrent use.
+type Map[K comparable, V any] interface {
+	AppendOnlyMap[K, V]
+	// Store sets the value for a key.
+	Store(key K, value V)
+	// Delete deletes the value for a key.
+	Delete(key K)
+	// LoadAndDelete deletes the value for a key, returning the previous value if any. The second result reports whether the key was present.
+	LoadAndDelete(key K) (V, bool)
+}
+
+var (
+	_ Map[string, int]    = &MutexMap[string, int]{}
+	_ Map[string, int]    = &RWMutexMap[string, int]{}
+	_ Map[string, int]    = &SyncMap[string, int]{}
+	_ Map[string, int]    = &UnsyncedMap[string, int]{}
+	_ Map[string, int]    = &ShardedMap[string, int]{}
+	_ Map[string, int]    = &LRUMap[string, int]{}
+	_ Map[string, int]    = &WatchableMap[string, int]{}
+	_ Map[string, int]    = &LinkedMap[string, int]{}
+	_ Loader[string, int] = &TTLMap[string, int]{}
+)
@@ -0,0 +1,471 @@
+//go:build go1.23
+
+package mapz
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+)
+
+// All returns an iterator over the key-value pairs in the map. It has the same guarantees as Range; in particular, the lock is not held while yielding.
+func (m *MutexMap[K, V]) All() iter.Seq2[K, V] {
+	return m.Range
+}
+
+// Keys returns an iterator over the keys in the map. It has the same guarantees as Range.
+func (m *MutexMap[K, V]) Keys() iter.Seq[K] {
+	return seqKeys(m.Range)
+}
+
+// Values returns an iterator over the values in the map. It has the same guarantees as Range.
+func (m *MutexMap[K, V]) Values() iter.Seq[V] {
+	return seqValues(m.Range)
+}
+
+// StoreAllSeq sets the values for all key-value pairs yielded by seq. The lock is taken only once, so seq must not use m.
+func (m *MutexMap[K, V]) StoreAllSeq(seq iter.Seq2[K, V]) {
+	m.L.Lock()
+	defer m.L.Unlock()
+	if m.M == nil {
+		m.M = map[K]V{}
+	}
+	for k, v := range seq {
+		m.M[k] = v
+	}
+}
+
+// All returns an iterator over the key-value pairs in the map. It has the same guarantees as Range; in particular, the lock is not held while yielding.
+func (m *RWMutexMap[K, V]) All() iter.Seq2[K, V] {
+	return m.Range
+}
+
+// Keys returns an iterator over the keys in the map. It has the same guarantees as Range.
+func (m *RWMutexMap[K, V]) Keys() iter.Seq[K] {
+	return seqKeys(m.Range)
+}
+
+// Values returns an iterator over the values in the map. It has the same guarantees as Range.
+func (m *RWMutexMap[K, V]) Values() iter.Seq[V] {
+	return seqValues(m.Range)
+}
+
+// StoreAllSeq sets the values for all key-value pairs yielded by seq. The lock is taken only once, so seq must not use m.
+func (m *RWMutexMap[K, V]) StoreAllSeq(seq iter.Seq2[K, V]) {
+	m.L.Lock()
+	defer m.L.Unlock()
+	if m.M == nil {
+		m.M = map[K]V{}
+	}
+	for k, v := range seq {
+		m.M[k] = v
+	}
+}
+
+// All returns an iterator over the key-value pairs in the map. It has the same guarantees as Range; in particular, it doesn't correspond to a consistent snapshot.
+func (m *SyncMap[K, V]) All() iter.Seq2[K, V] {
+	return m.Range
+}
+
+// Keys returns an iterator over the keys in the map. It has the same guarantees as Range.
+func (m *SyncMap[K, V]) Keys() iter.Seq[K] {
+	return seqKeys(m.Range)
+}
+
+// Values returns an iterator over the values in the map. It has the same guarantees as Range.
+func (m *SyncMap[K, V]) Values() iter.Seq[V] {
+	return seqValues(m.Range)
+}
+
+// StoreAllSeq sets the values for all key-value pairs yielded by seq.
+// SyncMap doesn't have a single lock, so this is the same as calling Store for every pair.
+func (m *SyncMap[K, V]) StoreAllSeq(seq iter.Seq2[K, V]) {
+	for k, v := range seq {
+		m.m.Store(k, v)
+	}
+}
+
+// All returns an iterator over the key-value pairs in the map.
+func (m *UnsyncedMap[K, V]) All() iter.Seq2[K, V] {
+	return m.Range
+}
+
+// Keys returns an iterator over the keys in the map.
+func (m *UnsyncedMap[K, V]) Keys() iter.Seq[K] {
+	return seqKeys(m.Range)
+}
+
+// Values returns an iterator over the values in the map.
+func (m *UnsyncedMap[K, V]) Values() iter.Seq[V] {
+	return seqValues(m.Range)
+}
+
+// StoreAllSeq sets the values for all key-value pairs yielded by seq.
+func (m *UnsyncedMap[K, V]) StoreAllSeq(seq iter.Seq2[K, V]) {
+	if m.M == nil {
+		m.M = map[K]V{}
+	}
+	for k, v := range seq {
+		m.M[k] = v
+	}
+}
+
+// All returns an iterator over the key-value pairs in the map. It has the same guarantees as Range; in particular, it iterates over a consistent snapshot taken when the iteration starts.
+func (m *AppendMap[K, V]) All() iter.Seq2[K, V] {
+	return m.Range
+}
+
+// Keys returns an iterator over the keys in the map. It has the same guarantees as Range.
+func (m *AppendMap[K, V]) Keys() iter.Seq[K] {
+	return seqKeys(m.Range)
+}
+
+// Values returns an iterator over the values in the map. It has the same guarantees as Range.
+func (m *AppendMap[K, V]) Values() iter.Seq[V] {
+	return seqValues(m.Range)
+}
+
+// StoreAllSeq stores all key-value pairs yielded by seq, except for keys that are already present. See StoreAll.
+// The lock is taken only once, so seq must not use m.
+func (m *AppendMap[K, V]) StoreAllSeq(seq iter.Seq2[K, V]) {
+	m.l.Lock()
+	defer m.l.Unlock()
+	stored := false
+	for k, v := range seq {
+		if _, ok := m.peekLocked(k); !ok {
+			m.storeLocked(k, v)
+			stored = true
+		}
+	}
+	if stored {
+		m.promote()
+	}
+}
+
+// All returns an iterator over the key-value pairs in the map. It has the same guarantees as Range; in particular, no lock is held while yielding.
+func (m *ShardedMap[K, V]) All() iter.Seq2[K, V] {
+	return m.Range
+}
+
+// Keys returns an iterator over the keys in the map. It has the same guarantees as Range.
+func (m *ShardedMap[K, V]) Keys() iter.Seq[K] {
+	return seqKeys(m.Range)
+}
+
+// Values returns an iterator over the values in the map. It has the same guarantees as Range.
+func (m *ShardedMap[K, V]) Values() iter.Seq[V] {
+	return seqValues(m.Range)
+}
+
+// All returns an iterator over the unexpired key-value pairs in the map. It has the same guarantees as Range.
+func (m *TTLMap[K, V]) All() iter.Seq2[K, V] {
+	return m.Range
+}
+
+// Keys returns an iterator over the unexpired keys in the map. It has the same guarantees as Range.
+func (m *TTLMap[K, V]) Keys() iter.Seq[K] {
+	return seqKeys(m.Range)
+}
+
+// Values returns an iterator over the unexpired values in the map. It has the same guarantees as Range.
+func (m *TTLMap[K, V]) Values() iter.Seq[V] {
+	return seqValues(m.Range)
+}
+
+// All returns an iterator over the key-value pairs in the map, from most to least recently used. It has the same guarantees as Range.
+func (m *LRUMap[K, V]) All() iter.Seq2[K, V] {
+	return m.Range
+}
+
+// Keys returns an iterator over the keys in the map, from most to least recently used. It has the same guarantees as Range.
+func (m *LRUMap[K, V]) Keys() iter.Seq[K] {
+	return seqKeys(m.Range)
+}
+
+// Values returns an iterator over the values in the map, from most to least recently used. It has the same guarantees as Range.
+func (m *LRUMap[K, V]) Values() iter.Seq[V] {
+	return seqValues(m.Range)
+}
+
+// All returns an iterator over the key-value pairs in the map. It has the same guarantees as Range.
+func (m *WatchableMap[K, V]) All() iter.Seq2[K, V] {
+	return m.Range
+}
+
+// Keys returns an iterator over the keys in the map. It has the same guarantees as Range.
+func (m *WatchableMap[K, V]) Keys() iter.Seq[K] {
+	return seqKeys(m.Range)
+}
+
+// Values returns an iterator over the values in the map. It has the same guarantees as Range.
+func (m *WatchableMap[K, V]) Values() iter.Seq[V] {
+	return seqValues(m.Range)
+}
+
+// All returns an iterator over the key-value pairs in the map. It has the same guarantees as Range.
+func (m *COWMap[K, V]) All() iter.Seq2[K, V] {
+	return m.Range
+}
+
+// Keys returns an iterator over the keys in the map. It has the same guarantees as Range.
+func (m *COWMap[K, V]) Keys() iter.Seq[K] {
+	return seqKeys(m.Range)
+}
+
+// Values returns an iterator over the values in the map. It has the same guarantees as Range.
+func (m *COWMap[K, V]) Values() iter.Seq[V] {
+	return seqValues(m.Range)
+}
+
+// StoreAllSeq sets the values for all key-value pairs yielded by seq. The map is copied only once and readers see either none or all of the new values.
+// The lock is held while iterating, so seq must not write to m.
+func (m *COWMap[K, V]) StoreAllSeq(seq iter.Seq2[K, V]) {
+	m.Update(func(n map[K]V) {
+		for k, v := range seq {
+			n[k] = v
+		}
+	})
+}
+
+// All returns an iterator over the key-value pairs in the map, in order. It has the same guarantees as Range.
+func (m *LinkedMap[K, V]) All() iter.Seq2[K, V] {
+	return m.Range
+}
+
+// Backward returns an iterator over the key-value pairs in the map, from back to front. It has the same guarantees as Range.
+func (m *LinkedMap[K, V]) Backward() iter.Seq2[K, V] {
+	return m.RangeBackward
+}
+
+// Keys returns an iterator over the keys in the map, in order. It has the same guarantees as Range.
+func (m *LinkedMap[K, V]) Keys() iter.Seq[K] {
+	return seqKeys(m.Range)
+}
+
+// Values returns an iterator over the values in the map, in order. It has the same guarantees as Range.
+func (m *LinkedMap[K, V]) Values() iter.Seq[V] {
+	return seqValues(m.Range)
+}
+
+// StoreAllSeq sets the values for all key-value pairs yielded by seq. New keys are added at the back in the order seq yields them.
+func (m *LinkedMap[K, V]) StoreAllSeq(seq iter.Seq2[K, V]) {
+	for k, v := range seq {
+		m.Store(k, v)
+	}
+}
+
+// All returns an iterator over the key-value pairs in the map, in ascending order. It has the same guarantees as Range.
+func (m *SortedMap[K, V]) All() iter.Seq2[K, V] {
+	return m.Range
+}
+
+// Backward returns an iterator over the key-value pairs in the map, in descending order. It has the same guarantees as Range.
+func (m *SortedMap[K, V]) Backward() iter.Seq2[K, V] {
+	return m.RangeBackward
+}
+
+// Keys returns an iterator over the keys in the map, in ascending order. It has the same guarantees as Range.
+func (m *SortedMap[K, V]) Keys() iter.Seq[K] {
+	return seqKeys(m.Range)
+}
+
+// Values returns an iterator over the values in the map, in ascending order of their keys. It has the same guarantees as Range.
+func (m *SortedMap[K, V]) Values() iter.Seq[V] {
+	return seqValues(m.Range)
+}
+
+// Between returns an iterator over the key-value pairs with keys greater than or equal to from and less than to, in ascending order. It has the same guarantees as Range.
+func (m *SortedMap[K, V]) Between(from, to K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.RangeBetween(from, to, yield)
+	}
+}
+
+// BetweenBackward is like Between, but iterates in descending order.
+func (m *SortedMap[K, V]) BetweenBackward(from, to K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.RangeBetweenBackward(from, to, yield)
+	}
+}
+
+// All returns an iterator over the key-value pairs in the map, in ascending order. It has the same guarantees as Range.
+func (m *SortedMapFunc[K, V]) All() iter.Seq2[K, V] {
+	return m.Range
+}
+
+// Backward returns an iterator over the key-value pairs in the map, in descending order. It has the same guarantees as Range.
+func (m *SortedMapFunc[K, V]) Backward() iter.Seq2[K, V] {
+	return m.RangeBackward
+}
+
+// Keys returns an iterator over the keys in the map, in ascending order. It has the same guarantees as Range.
+func (m *SortedMapFunc[K, V]) Keys() iter.Seq[K] {
+	return seqKeys(m.Range)
+}
+
+// Values returns an iterator over the values in the map, in ascending order of their keys. It has the same guarantees as Range.
+func (m *SortedMapFunc[K, V]) Values() iter.Seq[V] {
+	return seqValues(m.Range)
+}
+
+// Between returns an iterator over the key-value pairs with keys greater than or equal to from and less than to, in ascending order. It has the same guarantees as Range.
+func (m *SortedMapFunc[K, V]) Between(from, to K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.RangeBetween(from, to, yield)
+	}
+}
+
+// BetweenBackward is like Between, but iterates in descending order.
+func (m *SortedMapFunc[K, V]) BetweenBackward(from, to K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.RangeBetweenBackward(from, to, yield)
+	}
+}
+
+// All returns an iterator over the key-value pairs in the map, in ascending order. It has the same guarantees as Range.
+func (m *ConcurrentSortedMap[K, V]) All() iter.Seq2[K, V] {
+	return m.Range
+}
+
+// Backward returns an iterator over the key-value pairs in the map, in descending order. It has the same guarantees as RangeBackward.
+func (m *ConcurrentSortedMap[K, V]) Backward() iter.Seq2[K, V] {
+	return m.RangeBackward
+}
+
+// Keys returns an iterator over the keys in the map, in ascending order. It has the same guarantees as Range.
+func (m *ConcurrentSortedMap[K, V]) Keys() iter.Seq[K] {
+	return seqKeys(m.Range)
+}
+
+// Values returns an iterator over the values in the map, in ascending order of their keys. It has the same guarantees as Range.
+func (m *ConcurrentSortedMap[K, V]) Values() iter.Seq[V] {
+	return seqValues(m.Range)
+}
+
+// Between returns an iterator over the key-value pairs with keys greater than or equal to from and less than to, in ascending order. It has the same guarantees as Range.
+func (m *ConcurrentSortedMap[K, V]) Between(from, to K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.RangeBetween(from, to, yield)
+	}
+}
+
+// BetweenBackward is like Between, but iterates in descending order.
+func (m *ConcurrentSortedMap[K, V]) BetweenBackward(from, to K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		m.RangeBetweenBackward(from, to, yield)
+	}
+}
+
+// All returns an iterator over the key-value pairs in the map. A key is yielded once for each of its values. It has the same guarantees as Range.
+func (m *MultiMap[K, V]) All() iter.Seq2[K, V] {
+	return m.Range
+}
+
+// Keys returns an iterator over the keys in the map. It has the same guarantees as RangeKeys.
+func (m *MultiMap[K, V]) Keys() iter.Seq[K] {
+	return m.RangeKeys
+}
+
+// All returns an iterator over the key-value pairs in the map. A key is yielded once for each of its values. It has the same guarantees as Range.
+func (m *SetMultiMap[K, V]) All() iter.Seq2[K, V] {
+	return m.Range
+}
+
+// Keys returns an iterator over the keys in the map. It has the same guarantees as RangeKeys.
+func (m *SetMultiMap[K, V]) Keys() iter.Seq[K] {
+	return m.RangeKeys
+}
+
+// All returns an iterator over the key-value pairs in the map. A key is yielded once for each of its values. It has the same guarantees as Range.
+func (m *MutexMultiMap[K, V]) All() iter.Seq2[K, V] {
+	return m.Range
+}
+
+// Keys returns an iterator over the keys in the map. It has the same guarantees as RangeKeys.
+func (m *MutexMultiMap[K, V]) Keys() iter.Seq[K] {
+	return m.RangeKeys
+}
+
+// All returns an iterator over the key-value pairs in the map. A key is yielded once for each of its values. It has the same guarantees as Range.
+func (m *MutexSetMultiMap[K, V]) All() iter.Seq2[K, V] {
+	return m.Range
+}
+
+// Keys returns an iterator over the keys in the map. It has the same guarantees as RangeKeys.
+func (m *MutexSetMultiMap[K, V]) Keys() iter.Seq[K] {
+	return m.RangeKeys
+}
+
+// All returns an iterator over the key-value pairs in the map. It has the same guarantees as Range.
+func (m *BiMap[K, V]) All() iter.Seq2[K, V] {
+	return m.Range
+}
+
+// Keys returns an iterator over the keys in the map. It has the same guarantees as Range.
+func (m *BiMap[K, V]) Keys() iter.Seq[K] {
+	return seqKeys(m.Range)
+}
+
+// Values returns an iterator over the values in the map. It has the same guarantees as Range.
+func (m *BiMap[K, V]) Values() iter.Seq[V] {
+	return seqValues(m.Range)
+}
+
+// All returns an iterator over the key-value pairs in the map. It has the same guarantees as Range.
+func (m *MutexBiMap[K, V]) All() iter.Seq2[K, V] {
+	return m.Range
+}
+
+// Keys returns an iterator over the keys in the map. It has the same guarantees as Range.
+func (m *MutexBiMap[K, V]) Keys() iter.Seq[K] {
+	return seqKeys(m.Range)
+}
+
+// Values returns an iterator over the values in the map. It has the same guarantees as Range.
+func (m *MutexBiMap[K, V]) Values() iter.Seq[V] {
+	return seqValues(m.Range)
+}
+
+func seqKeys[K, V any](seq iter.Seq2[K, V]) iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range seq {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+func seqValues[K, V any](seq iter.Seq2[K, V]) iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for _, v := range seq {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Sorted returns an iterator over the key-value pairs from seq in ascending order of key, for example mapz.Sorted(m.All()).
+// seq is consumed entirely when the iteration starts, so Sorted has the consistency guarantees of seq, but never yields while seq is running.
+// If seq yields the same key multiple times, all pairs are yielded in the order seq yielded them.
+func Sorted[K cmp.Ordered, V any](seq iter.Seq2[K, V]) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		type kv struct {
+			k K
+			v V
+		}
+		var pairs []kv
+		for k, v := range seq {
+			pairs = append(pairs, kv{k, v})
+		}
+		slices.SortStableFunc(pairs, func(a, b kv) int {
+			return cmp.Compare(a.k, b.k)
+		})
+		for _, p := range pairs {
+			if !yield(p.k, p.v) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,210 @@
+//go:build go1.23
+
+package mapz
+
+import (
+	"iter"
+	"maps"
+	"slices"
+	"testing"
+)
+
+type iterable interface {
+	All() iter.Seq2[int, string]
+	Keys() iter.Seq[int]
+	Values() iter.Seq[string]
+}
+
+var iterableMaps = []struct {
+	name string
+	new  func(m map[int]string) iterable
+}{
+	{"MutexMap", func(m map[int]string) iterable { return &MutexMap[int, string]{M: m} }},
+	{"RWMutexMap", func(m map[int]string) iterable { return &RWMutexMap[int, string]{M: m} }},
+	{"UnsyncedMap", func(m map[int]string) iterable { return &UnsyncedMap[int, string]{M: m} }},
+	{"SyncMap", func(m map[int]string) iterable {
+		var ret SyncMap[int, string]
+		for k, v := range m {
+			ret.Store(k, v)
+		}
+		return &ret
+	}},
+	{"AppendMap", func(m map[int]string) iterable {
+		var ret AppendMap[int, string]
+		for k, v := range m {
+			ret.LoadOrStore(k, v)
+		}
+		return &ret
+	}},
+	{"SortedMap", func(m map[int]string) iterable {
+		var ret SortedMap[int, string]
+		for k, v := range m {
+			ret.Store(k, v)
+		}
+		return &ret
+	}},
+	{"ConcurrentSortedMap", func(m map[int]string) iterable {
+		var ret ConcurrentSortedMap[int, string]
+		for k, v := range m {
+			ret.Store(k, v)
+		}
+		return &ret
+	}},
+	{"LinkedMap", func(m map[int]string) iterable {
+		var ret LinkedMap[int, string]
+		ret.StoreAll(m)
+		return &ret
+	}},
+	{"COWMap", func(m map[int]string) iterable {
+		var ret COWMap[int, string]
+		ret.StoreAll(m)
+		return &ret
+	}},
+	{"ShardedMap", func(m map[int]string) iterable {
+		var ret ShardedMap[int, string]
+		for k, v := range m {
+			ret.Store(k, v)
+		}
+		return &ret
+	}},
+	{"TTLMap", func(m map[int]string) iterable {
+		var ret TTLMap[int, string]
+		for k, v := range m {
+			ret.Store(k, v, 0)
+		}
+		return &ret
+	}},
+	{"LRUMap", func(m map[int]string) iterable {
+		ret := NewLRUMap[int, string](10)
+		for k, v := range m {
+			ret.Store(k, v)
+		}
+		return ret
+	}},
+}
+
+func TestIterators(t *testing.T) {
+	want := map[int]string{1: "a", 2: "b", 3: "c"}
+	for _, tc := range iterableMaps {
+		t.Run(tc.name, func(t *testing.T) {
+			m := tc.new(maps.Clone(want))
+			if got := maps.Collect(m.All()); !maps.Equal(got, want) {
+				t.Errorf("All() yielded %v; want %v", got, want)
+			}
+			if got := slices.Sorted(m.Keys()); !slices.Equal(got, []int{1, 2, 3}) {
+				t.Errorf("Keys() yielded %v; want [1 2 3]", got)
+			}
+			if got := slices.Sorted(m.Values()); !slices.Equal(got, []string{"a", "b", "c"}) {
+				t.Errorf("Values() yielded %v; want [a b c]", got)
+			}
+			n := 0
+			for range m.All() {
+				n++
+				break
+			}
+			for range m.Keys() {
+				n++
+				break
+			}
+			for range m.Values() {
+				n++
+				break
+			}
+			if n != 3 {
+				t.Errorf("iterators didn't stop after break")
+			}
+		})
+	}
+}
+
+func TestIteratorsDontHoldLock(t *testing.T) {
+	m := MutexMap[int, string]{M: map[int]string{1: "a", 2: "b", 3: "c"}}
+	for k := range m.All() {
+		if !m.L.TryLock() {
+			t.Fatalf("MutexMap.All() yielded while holding the lock")
+		}
+		m.L.Unlock()
+		// Modifying the map while iterating must not deadlock.
+		m.Store(k+10, "x")
+	}
+	rw := RWMutexMap[int, string]{M: map[int]string{1: "a", 2: "b", 3: "c"}}
+	for range rw.Keys() {
+		if !rw.L.TryLock() {
+			t.Fatalf("RWMutexMap.Keys() yielded while holding the lock")
+		}
+		rw.L.Unlock()
+	}
+}
+
+func TestSorted(t *testing.T) {
+	m := MutexMap[int, string]{M: map[int]string{3: "c", 1: "a", 2: "b", 5: "e", 4: "d"}}
+	var got []int
+	for k, v := range Sorted(m.All()) {
+		if v != m.M[k] {
+			t.Errorf("Sorted yielded %d => %q; want %q", k, v, m.M[k])
+		}
+		got = append(got, k)
+	}
+	if !slices.Equal(got, []int{1, 2, 3, 4, 5}) {
+		t.Errorf("Sorted yielded %v; want [1 2 3 4 5]", got)
+	}
+	got = nil
+	for k := range Sorted(m.All()) {
+		got = append(got, k)
+		if k == 2 {
+			break
+		}
+	}
+	if !slices.Equal(got, []int{1, 2}) {
+		t.Errorf("Sorted with break yielded %v; want [1 2]", got)
+	}
+}
+
+func TestStoreAllSeq(t *testing.T) {
+	want := map[int]string{1: "a", 2: "b"}
+	for _, tc := range []struct {
+		name string
+		m    interface {
+			StoreAllSeq(seq iter.Seq2[int, string])
+			All() iter.Seq2[int, string]
+		}
+	}{
+		{"MutexMap", &MutexMap[int, string]{}},
+		{"RWMutexMap", &RWMutexMap[int, string]{}},
+		{"UnsyncedMap", &UnsyncedMap[int, string]{}},
+		{"SyncMap", &SyncMap[int, string]{}},
+		{"AppendMap", &AppendMap[int, string]{}},
+		{"COWMap", &COWMap[int, string]{}},
+		{"LinkedMap", &LinkedMap[int, string]{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.m.StoreAllSeq(maps.All(want))
+			if got := maps.Collect(tc.m.All()); !maps.Equal(got, want) {
+				t.Errorf("StoreAllSeq stored %v; want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestSortedMapIterators(t *testing.T) {
+	var m SortedMap[int, string]
+	for i := 0; i < 10; i++ {
+		m.Store(i, "")
+	}
+	if got, want := slices.Collect(seqKeys(m.Between(3, 6))), []int{3, 4, 5}; !slices.Equal(got, want) {
+		t.Errorf("Between(3, 6) = %v; want %v", got, want)
+	}
+	if got, want := slices.Collect(seqKeys(m.BetweenBackward(3, 6))), []int{5, 4, 3}; !slices.Equal(got, want) {
+		t.Errorf("BetweenBackward(3, 6) = %v; want %v", got, want)
+	}
+	var got []int
+	for k := range m.Backward() {
+		if k < 7 {
+			break
+		}
+		got = append(got, k)
+	}
+	if want := []int{9, 8, 7}; !slices.Equal(got, want) {
+		t.Errorf("Backward() = %v; want %v", got, want)
+	}
+}
@@ -0,0 +1,168 @@
+package mapz
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// point is a TextMarshaler, so it can be used as a JSON object key.
+type point struct {
+	X, Y int
+}
+
+func (p point) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%d,%d", p.X, p.Y)), nil
+}
+
+func (p *point) UnmarshalText(b []byte) error {
+	_, err := fmt.Sscanf(string(b), "%d,%d", &p.X, &p.Y)
+	return err
+}
+
+type jsonMap interface {
+	Store(key point, value string)
+	Load(key point) (string, bool)
+	Range(f func(key point, value string) bool)
+	json.Marshaler
+	json.Unmarshaler
+}
+
+func TestJSON(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		new  func() jsonMap
+	}{
+		{"MutexMap", func() jsonMap { return &MutexMap[point, string]{} }},
+		{"SyncMap", func() jsonMap { return &SyncMap[point, string]{} }},
+		{"UnsyncedMap", func() jsonMap { return &UnsyncedMap[point, string]{} }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := tc.new()
+			if b, err := json.Marshal(m); err != nil || string(b) != "{}" {
+				t.Errorf("json.Marshal(empty) = %s, %v; want {}", b, err)
+			}
+			m.Store(point{3, 1}, "c")
+			m.Store(point{1, 2}, "a")
+			m.Store(point{2, 0}, "b")
+			const want = `{"1,2":"a","2,0":"b","3,1":"c"}`
+			for i := 0; i < 5; i++ {
+				b, err := json.Marshal(m)
+				if err != nil {
+					t.Fatalf("json.Marshal failed: %v", err)
+				}
+				if string(b) != want {
+					t.Fatalf("json.Marshal() = %s; want %s", b, want)
+				}
+			}
+
+			n := tc.new()
+			n.Store(point{9, 9}, "removed")
+			if err := json.Unmarshal([]byte(want), n); err != nil {
+				t.Fatalf("json.Unmarshal failed: %v", err)
+			}
+			l := 0
+			n.Range(func(point, string) bool {
+				l++
+				return true
+			})
+			if l != 3 {
+				t.Errorf("map has %d entries after Unmarshal; want 3", l)
+			}
+			if v, ok := n.Load(point{1, 2}); !ok || v != "a" {
+				t.Errorf("Load(1,2) = %q, %v; want a, true", v, ok)
+			}
+			if _, ok := n.Load(point{9, 9}); ok {
+				t.Errorf("Unmarshal didn't replace the existing contents")
+			}
+			if err := json.Unmarshal([]byte(`["not", "an", "object"]`), n); err == nil {
+				t.Errorf("json.Unmarshal of an array succeeded")
+			}
+		})
+	}
+}
+
+func TestJSONEmbedded(t *testing.T) {
+	type config struct {
+		Name    string
+		Weights MutexMap[string, int]
+		Labels  SyncMap[string, string]
+		Extra   UnsyncedMap[int, bool]
+	}
+	var c config
+	c.Name = "test"
+	c.Weights.Store("b", 2)
+	c.Weights.Store("a", 1)
+	c.Labels.Store("env", "prod")
+	c.Extra.Store(10, true)
+	c.Extra.Store(9, false)
+	b, err := json.Marshal(&c)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %v", err)
+	}
+	const want = `{"Name":"test","Weights":{"a":1,"b":2},"Labels":{"env":"prod"},"Extra":{"9":false,"10":true}}`
+	if string(b) != want {
+		t.Errorf("json.Marshal() = %s; want %s", b, want)
+	}
+	var d config
+	if err := json.NewDecoder(strings.NewReader(want)).Decode(&d); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if d.Weights.LoadOrZero("b") != 2 || d.Labels.LoadOrZero("env") != "prod" || !d.Extra.LoadOrZero(10) || d.Extra.Len() != 2 {
+		t.Errorf("Decode() = %+v; want the original config", &d)
+	}
+}
+
+func TestJSONIntegerKeys(t *testing.T) {
+	var mm MutexMap[int8, bool]
+	var sm SyncMap[uint, bool]
+	var um UnsyncedMap[int, bool]
+	for _, k := range []int{10, 9, 100, 1} {
+		mm.Store(int8(-k), k%2 == 0)
+		sm.Store(uint(k), k%2 == 0)
+		um.Store(k, k%2 == 0)
+	}
+	for _, tc := range []struct {
+		m    any
+		want string
+	}{
+		{&mm, `{"-100":true,"-10":true,"-9":false,"-1":false}`},
+		{&sm, `{"1":false,"9":false,"10":true,"100":true}`},
+		{um, `{"1":false,"9":false,"10":true,"100":true}`},
+	} {
+		b, err := json.Marshal(tc.m)
+		if err != nil {
+			t.Fatalf("json.Marshal(%T) failed: %v", tc.m, err)
+		}
+		if string(b) != tc.want {
+			t.Errorf("json.Marshal(%T) = %s; want %s", tc.m, b, tc.want)
+		}
+	}
+}
+
+func TestJSONEmbeddedByValue(t *testing.T) {
+	// Only UnsyncedMap has no lock, so it's the only one that can be marshaled by value. See the MutexMap, SyncMap and AppendMap docs.
+	type config struct {
+		Name  string
+		Extra UnsyncedMap[string, int]
+	}
+	var c config
+	c.Name = "test"
+	c.Extra.Store("b", 2)
+	c.Extra.Store("a", 1)
+	b, err := json.Marshal(c)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %v", err)
+	}
+	if want := `{"Name":"test","Extra":{"a":1,"b":2}}`; string(b) != want {
+		t.Errorf("json.Marshal() = %s; want %s", b, want)
+	}
+	b, err = json.Marshal(map[string]UnsyncedMap[string, int]{"x": c.Extra, "y": {}})
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %v", err)
+	}
+	if want := `{"x":{"a":1,"b":2},"y":{}}`; string(b) != want {
+		t.Errorf("json.Marshal() = %s; want %s", b, want)
+	}
+}
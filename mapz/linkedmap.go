@@ -0,0 +1,423 @@
+package mapz
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"golang.org/x/exp/maps"
+)
+
+// LinkedMap is a map that remembers the order in which keys were inserted. Its interface closely resembles UnsyncedMap.
+// Range and the other iteration methods visit keys in insertion order, which makes LinkedMap useful for golden tests and order-sensitive output.
+// Storing a key that is already present doesn't change its position. Use MoveToFront and MoveToBack to reorder keys.
+//
+// Like UnsyncedMap it isn't safe for concurrent use. All single key operations are O(1).
+// The zero value is valid. A LinkedMap must not be copied after first use.
+type LinkedMap[K comparable, V any] struct {
+	m    map[K]*linkedEntry[K, V]
+	root linkedEntry[K, V]
+}
+
+// linkedEntry is an element of the circular doubly linked list rooted at LinkedMap.root. root.next is the first entry.
+type linkedEntry[K comparable, V any] struct {
+	key        K
+	value      V
+	prev, next *linkedEntry[K, V]
+}
+
+func (m *LinkedMap[K, V]) lazyInit() {
+	if m.m == nil {
+		m.m = map[K]*linkedEntry[K, V]{}
+		m.root.next = &m.root
+		m.root.prev = &m.root
+	}
+}
+
+func (m *LinkedMap[K, V]) unlink(e *linkedEntry[K, V]) {
+	e.prev.next = e.next
+	e.next.prev = e.prev
+	e.prev = nil
+	e.next = nil
+}
+
+// insertAfter links e into the list after at.
+func (m *LinkedMap[K, V]) insertAfter(e, at *linkedEntry[K, V]) {
+	e.prev = at
+	e.next = at.next
+	at.next.prev = e
+	at.next = e
+}
+
+// Load returns the value stored in the map for a key. The ok result indicates whether value was found in the map.
+func (m *LinkedMap[K, V]) Load(key K) (V, bool) {
+	if e, ok := m.m[key]; ok {
+		return e.value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// LoadOrZero returns the value stored in the map for a key, or zero if no value is present. This is the same as Load() but ignoring the second result.
+func (m *LinkedMap[K, V]) LoadOrZero(key K) V {
+	v, _ := m.Load(key)
+	return v
+}
+
+// Store sets the value for a key. New keys are added at the back. Existing keys keep their position.
+func (m *LinkedMap[K, V]) Store(key K, value V) {
+	m.Swap(key, value)
+}
+
+// Delete deletes the value for a key.
+func (m *LinkedMap[K, V]) Delete(key K) {
+	m.LoadAndDelete(key)
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if any. The second result reports whether the key was present.
+func (m *LinkedMap[K, V]) LoadAndDelete(key K) (V, bool) {
+	e, ok := m.m[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	m.unlink(e)
+	delete(m.m, key)
+	return e.value, true
+}
+
+// LoadOrStore returns the existing value for the key if present. Otherwise, it stores the given value at the back and returns it. The loaded result is true if the value was loaded, false if stored.
+func (m *LinkedMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	if e, ok := m.m[key]; ok {
+		return e.value, true
+	}
+	m.Store(key, value)
+	return value, false
+}
+
+// Swap swaps the value for a key and returns the previous value if any. The loaded result reports whether the key was present.
+// New keys are added at the back. Existing keys keep their position.
+func (m *LinkedMap[K, V]) Swap(key K, value V) (previous V, loaded bool) {
+	m.lazyInit()
+	if e, ok := m.m[key]; ok {
+		previous = e.value
+		e.value = value
+		return previous, true
+	}
+	e := &linkedEntry[K, V]{key: key, value: value}
+	m.m[key] = e
+	m.insertAfter(e, m.root.prev)
+	return previous, false
+}
+
+// CompareAndDelete deletes the entry for key if its value is equal to old.
+//
+// If there is no current value for key in the map, CompareAndDelete returns false.
+//
+// This is a function rather than a method because Go 1.18 doesn't allow restricting a method's type parameters more than the base type (yet?).
+func LinkedMapCompareAndDelete[K, V comparable](m *LinkedMap[K, V], key K, old V) (deleted bool) {
+	if e, ok := m.m[key]; ok && e.value == old {
+		m.Delete(key)
+		return true
+	}
+	return false
+}
+
+// CompareAndSwap swaps the old and new values for key if the value stored in the map is equal to old. The old value must be of a comparable type.
+//
+// This is a function rather than a method because Go 1.18 doesn't allow restricting a method's type parameters more than the base type (yet?).
+func LinkedMapCompareAndSwap[K, V comparable](m *LinkedMap[K, V], key K, old, new V) bool {
+	if m.LoadOrZero(key) == old {
+		m.Store(key, new)
+		return true
+	}
+	return false
+}
+
+// MoveToFront moves key to the front of the map. It returns false if the key isn't present.
+func (m *LinkedMap[K, V]) MoveToFront(key K) bool {
+	e, ok := m.m[key]
+	if !ok {
+		return false
+	}
+	m.unlink(e)
+	m.insertAfter(e, &m.root)
+	return true
+}
+
+// MoveToBack moves key to the back of the map. It returns false if the key isn't present.
+func (m *LinkedMap[K, V]) MoveToBack(key K) bool {
+	e, ok := m.m[key]
+	if !ok {
+		return false
+	}
+	m.unlink(e)
+	m.insertAfter(e, m.root.prev)
+	return true
+}
+
+// First returns the key and value at the front of the map. The ok result is false if the map is empty.
+func (m *LinkedMap[K, V]) First() (key K, value V, ok bool) {
+	if len(m.m) == 0 {
+		return key, value, false
+	}
+	return m.root.next.key, m.root.next.value, true
+}
+
+// Last returns the key and value at the back of the map. The ok result is false if the map is empty.
+func (m *LinkedMap[K, V]) Last() (key K, value V, ok bool) {
+	if len(m.m) == 0 {
+		return key, value, false
+	}
+	return m.root.prev.key, m.root.prev.value, true
+}
+
+// StoreAll sets the values for all keys in entries.
+// New keys are added at the back in the iteration order of entries, which is random. Use Store or StoreAllSeq if you need a defined order.
+func (m *LinkedMap[K, V]) StoreAll(entries map[K]V) {
+	for k, v := range entries {
+		m.Store(k, v)
+	}
+}
+
+// LoadMany returns the values stored in the map for the given keys. Keys that aren't present are omitted from the result.
+func (m *LinkedMap[K, V]) LoadMany(keys ...K) map[K]V {
+	ret := make(map[K]V, len(keys))
+	for _, k := range keys {
+		if e, ok := m.m[k]; ok {
+			ret[k] = e.value
+		}
+	}
+	return ret
+}
+
+// DeleteMany deletes the values for the given keys.
+func (m *LinkedMap[K, V]) DeleteMany(keys ...K) {
+	for _, k := range keys {
+		m.Delete(k)
+	}
+}
+
+// LoadAndDeleteAll deletes all values from the map and returns them.
+func (m *LinkedMap[K, V]) LoadAndDeleteAll() map[K]V {
+	ret := make(map[K]V, len(m.m))
+	for k, e := range m.m {
+		ret[k] = e.value
+	}
+	m.m = nil
+	m.root = linkedEntry[K, V]{}
+	return ret
+}
+
+// Range calls f sequentially for each key and value present in the map, in order. If f returns false, range stops the iteration.
+//
+// f may modify the map. Range visits the keys that were present when it started, in the order they had then, and skips keys that are deleted before they are visited.
+func (m *LinkedMap[K, V]) Range(f func(key K, value V) bool) {
+	m.rangeEntries(m.entries(false), f)
+}
+
+// RangeBackward is like Range, but iterates from back to front.
+func (m *LinkedMap[K, V]) RangeBackward(f func(key K, value V) bool) {
+	m.rangeEntries(m.entries(true), f)
+}
+
+// entries returns the entries of the map in order.
+func (m *LinkedMap[K, V]) entries(backward bool) []*linkedEntry[K, V] {
+	if len(m.m) == 0 {
+		return nil
+	}
+	ret := make([]*linkedEntry[K, V], 0, len(m.m))
+	if backward {
+		for e := m.root.prev; e != &m.root; e = e.prev {
+			ret = append(ret, e)
+		}
+	} else {
+		for e := m.root.next; e != &m.root; e = e.next {
+			ret = append(ret, e)
+		}
+	}
+	return ret
+}
+
+func (m *LinkedMap[K, V]) rangeEntries(entries []*linkedEntry[K, V], f func(key K, value V) bool) {
+	for _, e := range entries {
+		if m.m[e.key] != e {
+			// Deleted by f.
+			continue
+		}
+		if !f(e.key, e.value) {
+			return
+		}
+	}
+}
+
+// Len returns the number of elements in the map.
+func (m *LinkedMap[K, V]) Len() int {
+	return len(m.m)
+}
+
+// MarshalJSON encodes the map as a JSON object with the keys in order. Keys are encoded with the same rules as encoding/json uses for maps: they must be strings, integers or implement encoding.TextMarshaler.
+func (m *LinkedMap[K, V]) MarshalJSON() ([]byte, error) {
+	return marshalJSONObject(m.Range)
+}
+
+// UnmarshalJSON replaces the contents of the map with the given JSON object, preserving the order of the keys. If a key occurs more than once, the last value wins but the key keeps its first position.
+func (m *LinkedMap[K, V]) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	var n LinkedMap[K, V]
+	if t == nil {
+		*m = n
+		return nil
+	}
+	if d, ok := t.(json.Delim); !ok || d != '{' {
+		return fmt.Errorf("mapz: cannot unmarshal %v into LinkedMap", t)
+	}
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		var k K
+		if err := parseJSONKey(t.(string), &k); err != nil {
+			return err
+		}
+		var v V
+		if err := dec.Decode(&v); err != nil {
+			return err
+		}
+		n.Store(k, v)
+	}
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	// n can't simply be copied into m, because its list points at n.root.
+	m.LoadAndDeleteAll()
+	n.Range(func(k K, v V) bool {
+		m.Store(k, v)
+		return true
+	})
+	return nil
+}
+
+// marshalJSONObject encodes the entries visited by rangeFn as a JSON object, in the order they're visited.
+func marshalJSONObject[K comparable, V any](rangeFn func(f func(K, V) bool)) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	first := true
+	var err error
+	rangeFn(func(k K, v V) bool {
+		var ks string
+		ks, err = jsonKeyString(k)
+		if err != nil {
+			return false
+		}
+		var kb, vb []byte
+		if kb, err = json.Marshal(ks); err != nil {
+			return false
+		}
+		if vb, err = json.Marshal(v); err != nil {
+			return false
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		buf.Write(kb)
+		buf.WriteByte(':')
+		buf.Write(vb)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// marshalSortedJSON encodes m like json.Marshal does, except that integer keys are sorted by their value rather than as strings. A nil map is encoded as {}.
+func marshalSortedJSON[K comparable, V any](m map[K]V) ([]byte, error) {
+	if m == nil {
+		return []byte("{}"), nil
+	}
+	var less func(a, b reflect.Value) bool
+	// encoding/json already sorts string keys by value, and encodes integer keys that implement encoding.TextMarshaler as text.
+	if t := reflect.TypeOf((*K)(nil)).Elem(); !t.Implements(reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()) {
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			less = func(a, b reflect.Value) bool { return a.Int() < b.Int() }
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			less = func(a, b reflect.Value) bool { return a.Uint() < b.Uint() }
+		}
+	}
+	if less == nil {
+		return json.Marshal(m)
+	}
+	keys := maps.Keys(m)
+	sort.Slice(keys, func(i, j int) bool {
+		return less(reflect.ValueOf(keys[i]), reflect.ValueOf(keys[j]))
+	})
+	return marshalJSONObject(func(f func(K, V) bool) {
+		for _, k := range keys {
+			if !f(k, m[k]) {
+				return
+			}
+		}
+	})
+}
+
+// jsonKeyString converts a map key to a string like encoding/json does.
+func jsonKeyString(k any) (string, error) {
+	rv := reflect.ValueOf(k)
+	if rv.Kind() == reflect.String {
+		return rv.String(), nil
+	}
+	if tm, ok := k.(encoding.TextMarshaler); ok {
+		b, err := tm.MarshalText()
+		return string(b), err
+	}
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	}
+	return "", fmt.Errorf("mapz: unsupported JSON key type %T", k)
+}
+
+// parseJSONKey parses a JSON object key into k like encoding/json does.
+// Unlike jsonKeyString, it prefers encoding.TextUnmarshaler over the string kind, because that's what encoding/json does when decoding.
+func parseJSONKey(s string, k any) error {
+	if tu, ok := k.(encoding.TextUnmarshaler); ok {
+		return tu.UnmarshalText([]byte(s))
+	}
+	rv := reflect.ValueOf(k).Elem()
+	if rv.Kind() == reflect.String {
+		rv.SetString(s)
+		return nil
+	}
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, rv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("mapz: invalid JSON key %q for %s: %w", s, rv.Type(), err)
+		}
+		rv.SetInt(n)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(s, 10, rv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("mapz: invalid JSON key %q for %s: %w", s, rv.Type(), err)
+		}
+		rv.SetUint(n)
+		return nil
+	}
+	return fmt.Errorf("mapz: unsupported JSON key type %s", rv.Type())
+}
@@ -0,0 +1,182 @@
+package mapz
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// lowerKey is a string type with its own UnmarshalText, which encoding/json prefers over setting the string directly.
+type lowerKey string
+
+func (k *lowerKey) UnmarshalText(b []byte) error {
+	*k = lowerKey(strings.ToLower(string(b)))
+	return nil
+}
+
+func linkedMapKeys[K comparable, V any](m *LinkedMap[K, V]) []K {
+	var ret []K
+	m.Range(func(k K, v V) bool {
+		ret = append(ret, k)
+		return true
+	})
+	return ret
+}
+
+func TestLinkedMap(t *testing.T) {
+	var m LinkedMap[string, int]
+	if _, _, ok := m.First(); ok {
+		t.Errorf("First() on an empty map succeeded")
+	}
+	if _, _, ok := m.Last(); ok {
+		t.Errorf("Last() on an empty map succeeded")
+	}
+	for i, k := range []string{"c", "a", "d", "b"} {
+		m.Store(k, i)
+	}
+	m.Store("a", 10)
+	if v, loaded := m.LoadOrStore("e", 4); loaded || v != 4 {
+		t.Errorf("LoadOrStore(e) = %d, %v; want 4, false", v, loaded)
+	}
+	if got, want := linkedMapKeys(&m), []string{"c", "a", "d", "b", "e"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("keys = %v; want %v", got, want)
+	}
+	m.Delete("d")
+	if !m.MoveToFront("b") || !m.MoveToBack("c") || m.MoveToFront("x") || m.MoveToBack("x") {
+		t.Errorf("MoveToFront/MoveToBack returned unexpected results")
+	}
+	if got, want := linkedMapKeys(&m), []string{"b", "a", "e", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("keys = %v; want %v", got, want)
+	}
+	if k, v, ok := m.First(); !ok || k != "b" || v != 3 {
+		t.Errorf("First() = %q, %d, %v; want b, 3, true", k, v, ok)
+	}
+	if k, v, ok := m.Last(); !ok || k != "c" || v != 0 {
+		t.Errorf("Last() = %q, %d, %v; want c, 0, true", k, v, ok)
+	}
+	var backward []string
+	m.RangeBackward(func(k string, v int) bool {
+		backward = append(backward, k)
+		return true
+	})
+	if want := []string{"c", "e", "a", "b"}; !reflect.DeepEqual(backward, want) {
+		t.Errorf("RangeBackward visited %v; want %v", backward, want)
+	}
+	if !LinkedMapCompareAndSwap(&m, "a", 10, 11) || LinkedMapCompareAndSwap(&m, "a", 10, 12) {
+		t.Errorf("LinkedMapCompareAndSwap returned unexpected results")
+	}
+	if !LinkedMapCompareAndDelete(&m, "a", 11) || LinkedMapCompareAndDelete(&m, "e", 0) {
+		t.Errorf("LinkedMapCompareAndDelete returned unexpected results")
+	}
+	if got, want := m.LoadAndDeleteAll(), map[string]int{"b": 3, "e": 4, "c": 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadAndDeleteAll() = %v; want %v", got, want)
+	}
+	if m.Len() != 0 {
+		t.Errorf("Len() = %d; want 0", m.Len())
+	}
+	m.Store("z", 1)
+	if got, want := linkedMapKeys(&m), []string{"z"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("keys after LoadAndDeleteAll = %v; want %v", got, want)
+	}
+}
+
+func TestLinkedMapModifyDuringRange(t *testing.T) {
+	var m LinkedMap[int, int]
+	for i := 0; i < 6; i++ {
+		m.Store(i, i)
+	}
+	var visited []int
+	m.Range(func(k, v int) bool {
+		visited = append(visited, k)
+		switch k {
+		case 1:
+			// Delete the current and the next key.
+			m.Delete(1)
+			m.Delete(2)
+		case 3:
+			m.Store(4, 40)
+			m.Store(6, 6)
+			m.MoveToFront(5)
+		}
+		return true
+	})
+	if want := []int{0, 1, 3, 4, 5}; !reflect.DeepEqual(visited, want) {
+		t.Errorf("Range visited %v; want %v", visited, want)
+	}
+	if got, want := linkedMapKeys(&m), []int{5, 0, 3, 4, 6}; !reflect.DeepEqual(got, want) {
+		t.Errorf("keys = %v; want %v", got, want)
+	}
+}
+
+func TestLinkedMapJSON(t *testing.T) {
+	var m LinkedMap[string, int]
+	m.Store("zebra", 1)
+	m.Store("apple", 2)
+	m.Store("quote\"", 3)
+	b, err := json.Marshal(&m)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %v", err)
+	}
+	if want := `{"zebra":1,"apple":2,"quote\"":3}`; string(b) != want {
+		t.Errorf("json.Marshal() = %s; want %s", b, want)
+	}
+
+	var n LinkedMap[string, int]
+	n.Store("old", 0)
+	if err := json.Unmarshal([]byte(`{"b": 1, "a": 2, "b": 3}`), &n); err != nil {
+		t.Fatalf("json.Unmarshal failed: %v", err)
+	}
+	if got, want := linkedMapKeys(&n), []string{"b", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("keys = %v; want %v", got, want)
+	}
+	if v := n.LoadOrZero("b"); v != 3 {
+		t.Errorf("LoadOrZero(b) = %d; want 3", v)
+	}
+
+	var p LinkedMap[point, bool]
+	p.Store(point{2, 1}, true)
+	p.Store(point{1, 2}, false)
+	b, err = json.Marshal(&p)
+	if err != nil {
+		t.Fatalf("json.Marshal failed: %v", err)
+	}
+	if want := `{"2,1":true,"1,2":false}`; string(b) != want {
+		t.Errorf("json.Marshal() = %s; want %s", b, want)
+	}
+	var q LinkedMap[point, bool]
+	if err := json.Unmarshal(b, &q); err != nil {
+		t.Fatalf("json.Unmarshal failed: %v", err)
+	}
+	if got, want := linkedMapKeys(&q), []point{{2, 1}, {1, 2}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("keys = %v; want %v", got, want)
+	}
+
+	var i LinkedMap[int8, string]
+	if err := json.Unmarshal([]byte(`{"-3": "a", "7": "b"}`), &i); err != nil {
+		t.Fatalf("json.Unmarshal failed: %v", err)
+	}
+	if b, err := json.Marshal(&i); err != nil || string(b) != `{"-3":"a","7":"b"}` {
+		t.Errorf("json.Marshal() = %s, %v; want {\"-3\":\"a\",\"7\":\"b\"}", b, err)
+	}
+	if err := json.Unmarshal([]byte(`{"300": "overflow"}`), &i); err == nil {
+		t.Errorf("json.Unmarshal with an out of range key succeeded")
+	}
+	const mixedCase = `{"B": 1, "a": 2}`
+	var l LinkedMap[lowerKey, int]
+	if err := json.Unmarshal([]byte(mixedCase), &l); err != nil {
+		t.Fatalf("json.Unmarshal failed: %v", err)
+	}
+	var std map[lowerKey]int
+	if err := json.Unmarshal([]byte(mixedCase), &std); err != nil {
+		t.Fatalf("json.Unmarshal failed: %v", err)
+	}
+	if got, want := l.LoadMany("a", "b"), std; !reflect.DeepEqual(got, want) {
+		t.Errorf("LinkedMap entries = %v; want %v like encoding/json", got, want)
+	}
+	var f LinkedMap[float64, string]
+	f.Store(1.5, "x")
+	if _, err := json.Marshal(&f); err == nil {
+		t.Errorf("json.Marshal with float keys succeeded")
+	}
+}
@@ -0,0 +1,244 @@
+package mapz
+
+import "sync"
+
+// LRUMap is a size-bounded map protected with a mutex. When it is full, storing a new key evicts the least recently used entry.
+// Its interface closely resembles MutexMap.
+// The zero value is valid but has no capacity limit until Resize is called. Use NewLRUMap to create a bounded map.
+type LRUMap[K comparable, V any] struct {
+	// OnEvict is called for every entry that is removed to make room for new entries. It is not called for entries that are deleted or overwritten.
+	// OnEvict is called without holding the lock, so it may call any method on the map. It should be set before the map is first used.
+	OnEvict func(key K, value V)
+
+	l        sync.Mutex
+	m        map[K]*lruEntry[K, V]
+	root     lruEntry[K, V]
+	capacity int
+	stats    LRUStats
+}
+
+// LRUStats contains counters about the usage of an LRUMap.
+type LRUStats struct {
+	// Hits is the number of Load, LoadOrZero and LoadOrStore calls that found the key.
+	Hits uint64
+	// Misses is the number of Load, LoadOrZero and LoadOrStore calls that didn't find the key.
+	Misses uint64
+	// Evictions is the number of entries that were removed to make room.
+	Evictions uint64
+}
+
+// lruEntry is an element of the circular doubly linked list rooted at LRUMap.root. root.next is the most recently used entry.
+type lruEntry[K comparable, V any] struct {
+	key        K
+	value      V
+	prev, next *lruEntry[K, V]
+}
+
+// NewLRUMap creates an LRUMap that holds at most capacity entries. A capacity of 0 or less means unbounded.
+func NewLRUMap[K comparable, V any](capacity int) *LRUMap[K, V] {
+	return &LRUMap[K, V]{
+		capacity: capacity,
+	}
+}
+
+func (m *LRUMap[K, V]) lazyInit() {
+	if m.m == nil {
+		m.m = map[K]*lruEntry[K, V]{}
+		m.root.next = &m.root
+		m.root.prev = &m.root
+	}
+}
+
+func (m *LRUMap[K, V]) unlink(e *lruEntry[K, V]) {
+	e.prev.next = e.next
+	e.next.prev = e.prev
+	e.prev = nil
+	e.next = nil
+}
+
+func (m *LRUMap[K, V]) pushFront(e *lruEntry[K, V]) {
+	e.prev = &m.root
+	e.next = m.root.next
+	m.root.next.prev = e
+	m.root.next = e
+}
+
+func (m *LRUMap[K, V]) moveToFront(e *lruEntry[K, V]) {
+	if m.root.next == e {
+		return
+	}
+	m.unlink(e)
+	m.pushFront(e)
+}
+
+// evictLocked removes entries until the map is within its capacity and returns the removed entries. m.l must be held.
+func (m *LRUMap[K, V]) evictLocked() []*lruEntry[K, V] {
+	if m.capacity <= 0 {
+		return nil
+	}
+	var evicted []*lruEntry[K, V]
+	for len(m.m) > m.capacity {
+		e := m.root.prev
+		m.unlink(e)
+		delete(m.m, e.key)
+		evicted = append(evicted, e)
+		m.stats.Evictions++
+	}
+	return evicted
+}
+
+func (m *LRUMap[K, V]) evicted(evicted []*lruEntry[K, V]) {
+	if m.OnEvict == nil {
+		return
+	}
+	for _, e := range evicted {
+		m.OnEvict(e.key, e.value)
+	}
+}
+
+// Load returns the value stored in the map for a key and marks it as most recently used. The ok result indicates whether value was found in the map.
+func (m *LRUMap[K, V]) Load(key K) (V, bool) {
+	m.l.Lock()
+	defer m.l.Unlock()
+	e, ok := m.m[key]
+	if !ok {
+		m.stats.Misses++
+		var zero V
+		return zero, false
+	}
+	m.stats.Hits++
+	m.moveToFront(e)
+	return e.value, true
+}
+
+// LoadOrZero returns the value stored in the map for a key, or zero if no value is present. This is the same as Load() but ignoring the second result.
+func (m *LRUMap[K, V]) LoadOrZero(key K) V {
+	v, _ := m.Load(key)
+	return v
+}
+
+// Peek returns the value stored in the map for a key without marking it as recently used or updating the statistics. The ok result indicates whether value was found in the map.
+func (m *LRUMap[K, V]) Peek(key K) (V, bool) {
+	m.l.Lock()
+	defer m.l.Unlock()
+	if e, ok := m.m[key]; ok {
+		return e.value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Store sets the value for a key and marks it as most recently used. If this makes the map exceed its capacity, the least recently used entry is evicted.
+func (m *LRUMap[K, V]) Store(key K, value V) {
+	m.l.Lock()
+	m.lazyInit()
+	if e, ok := m.m[key]; ok {
+		e.value = value
+		m.moveToFront(e)
+		m.l.Unlock()
+		return
+	}
+	e := &lruEntry[K, V]{key: key, value: value}
+	m.m[key] = e
+	m.pushFront(e)
+	evicted := m.evictLocked()
+	m.l.Unlock()
+	m.evicted(evicted)
+}
+
+// LoadOrStore returns the existing value for the key if present. Otherwise, it stores and returns the given value. The loaded result is true if the value was loaded, false if stored.
+// Either way the key is marked as most recently used.
+func (m *LRUMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	m.l.Lock()
+	m.lazyInit()
+	if e, ok := m.m[key]; ok {
+		m.stats.Hits++
+		m.moveToFront(e)
+		v := e.value
+		m.l.Unlock()
+		return v, true
+	}
+	m.stats.Misses++
+	e := &lruEntry[K, V]{key: key, value: value}
+	m.m[key] = e
+	m.pushFront(e)
+	evicted := m.evictLocked()
+	m.l.Unlock()
+	m.evicted(evicted)
+	return value, false
+}
+
+// Delete deletes the value for a key.
+func (m *LRUMap[K, V]) Delete(key K) {
+	m.LoadAndDelete(key)
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if any. The second result reports whether the key was present.
+func (m *LRUMap[K, V]) LoadAndDelete(key K) (V, bool) {
+	m.l.Lock()
+	defer m.l.Unlock()
+	e, ok := m.m[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	m.unlink(e)
+	delete(m.m, key)
+	return e.value, true
+}
+
+// Resize changes the capacity of the map, evicting the least recently used entries if the map is now over capacity. A capacity of 0 or less means unbounded.
+func (m *LRUMap[K, V]) Resize(capacity int) {
+	m.l.Lock()
+	m.lazyInit()
+	m.capacity = capacity
+	evicted := m.evictLocked()
+	m.l.Unlock()
+	m.evicted(evicted)
+}
+
+// Capacity returns the maximum number of entries in the map. 0 means unbounded.
+func (m *LRUMap[K, V]) Capacity() int {
+	m.l.Lock()
+	defer m.l.Unlock()
+	if m.capacity < 0 {
+		return 0
+	}
+	return m.capacity
+}
+
+// Stats returns the hit, miss and eviction counters.
+func (m *LRUMap[K, V]) Stats() LRUStats {
+	m.l.Lock()
+	defer m.l.Unlock()
+	return m.stats
+}
+
+// Range calls f sequentially for each key and value present in the map, from most to least recently used. If f returns false, range stops the iteration.
+//
+// Range does not block other methods on the receiver; even f itself may call any method on m.
+//
+// Range iterates over a consistent copy of the map, snapshotted before the first callback. It doesn't mark entries as recently used.
+func (m *LRUMap[K, V]) Range(f func(key K, value V) bool) {
+	m.l.Lock()
+	m.lazyInit()
+	keys := make([]K, 0, len(m.m))
+	values := make([]V, 0, len(m.m))
+	for e := m.root.next; e != &m.root; e = e.next {
+		keys = append(keys, e.key)
+		values = append(values, e.value)
+	}
+	m.l.Unlock()
+	for i, k := range keys {
+		if !f(k, values[i]) {
+			return
+		}
+	}
+}
+
+// Len returns the number of elements in the map.
+func (m *LRUMap[K, V]) Len() int {
+	m.l.Lock()
+	defer m.l.Unlock()
+	return len(m.m)
+}
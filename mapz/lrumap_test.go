@@ -0,0 +1,136 @@
+package mapz
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func lruKeys(m *LRUMap[string, int]) []string {
+	var keys []string
+	m.Range(func(k string, v int) bool {
+		keys = append(keys, k)
+		return true
+	})
+	return keys
+}
+
+func TestLRUMap(t *testing.T) {
+	var evicted []string
+	m := NewLRUMap[string, int](3)
+	m.OnEvict = func(k string, v int) {
+		evicted = append(evicted, k)
+	}
+	m.Store("a", 1)
+	m.Store("b", 2)
+	m.Store("c", 3)
+	if got, want := lruKeys(m), []string{"c", "b", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Range order = %v; want %v", got, want)
+	}
+	if v, ok := m.Load("a"); !ok || v != 1 {
+		t.Errorf("Load(a) = %d, %v; want 1, true", v, ok)
+	}
+	if v, ok := m.Peek("b"); !ok || v != 2 {
+		t.Errorf("Peek(b) = %d, %v; want 2, true", v, ok)
+	}
+	m.Store("d", 4)
+	if got, want := evicted, []string{"b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("evicted = %v; want %v", got, want)
+	}
+	if got, want := lruKeys(m), []string{"d", "a", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Range order = %v; want %v", got, want)
+	}
+	if v, loaded := m.LoadOrStore("c", 5); !loaded || v != 3 {
+		t.Errorf("LoadOrStore(c, 5) = %d, %v; want 3, true", v, loaded)
+	}
+	if v, loaded := m.LoadOrStore("e", 5); loaded || v != 5 {
+		t.Errorf("LoadOrStore(e, 5) = %d, %v; want 5, false", v, loaded)
+	}
+	if got, want := lruKeys(m), []string{"e", "c", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Range order = %v; want %v", got, want)
+	}
+	if _, ok := m.Load("b"); ok {
+		t.Errorf("Load(b) found an evicted entry")
+	}
+	if got, want := m.Stats(), (LRUStats{Hits: 2, Misses: 2, Evictions: 2}); got != want {
+		t.Errorf("Stats() = %+v; want %+v", got, want)
+	}
+
+	m.Resize(1)
+	if got, want := lruKeys(m), []string{"e"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Range order after Resize = %v; want %v", got, want)
+	}
+	if got := m.Capacity(); got != 1 {
+		t.Errorf("Capacity() = %d; want 1", got)
+	}
+	if v, ok := m.LoadAndDelete("e"); !ok || v != 5 {
+		t.Errorf("LoadAndDelete(e) = %d, %v; want 5, true", v, ok)
+	}
+	if got := m.Len(); got != 0 {
+		t.Errorf("Len() = %d; want 0", got)
+	}
+	if got, want := evicted, []string{"b", "a", "d", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("evicted = %v; want %v", got, want)
+	}
+}
+
+func TestLRUMapZeroValue(t *testing.T) {
+	var m LRUMap[int, int]
+	for i := 0; i < 100; i++ {
+		m.Store(i, i)
+	}
+	m.Delete(50)
+	if got := m.Len(); got != 99 {
+		t.Errorf("Len() = %d; want 99", got)
+	}
+	m.Resize(10)
+	if got := m.Len(); got != 10 {
+		t.Errorf("Len() after Resize = %d; want 10", got)
+	}
+	if _, ok := m.Peek(99); !ok {
+		t.Errorf("most recently stored entry was evicted")
+	}
+}
+
+func TestLRUMapConcurrent(t *testing.T) {
+	m := NewLRUMap[int, int](16)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				m.Store(i%32, i)
+				m.Load(i % 32)
+				m.LoadOrStore(i%40, i)
+				if i%100 == 0 {
+					m.Range(func(k, v int) bool { return true })
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if got := m.Len(); got != 16 {
+		t.Errorf("Len() = %d; want 16", got)
+	}
+}
+
+func TestLRUMapLoadOrStoreRacesWithStore(t *testing.T) {
+	m := NewLRUMap[int, int](4)
+	m.Store(1, 0)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			m.Store(1, i)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			m.LoadOrStore(1, i)
+		}
+	}()
+	wg.Wait()
+}
@@ -76,7 +76,7 @@ func ValuesSorted[M ~map[K]V, K comparable, V constraints.Ordered](m M) []V {
 }
 
 // ValuesSortedByKey gets the values of the given map, sorts them by their key and returns them.
-// ValuesSortedByKey may fail to sort correctly when sorting slices of floating-point numbers containing Not-a-number (NaN) values.
+// NaN keys are ordered first.
 func ValuesSortedByKey[M ~map[K]V, K constraints.Ordered, V any](m M) []V {
 	keys := make([]K, 0, len(m))
 	values := make([]V, 0, len(m))
@@ -89,7 +89,7 @@ func ValuesSortedByKey[M ~map[K]V, K constraints.Ordered, V any](m M) []V {
 }
 
 // KeysSortedByValue gets the keys of the given map, sorts them by their value and returns them.
-// KeysSortedByValue may fail to sort correctly when sorting slices of floating-point numbers containing Not-a-number (NaN) values.
+// NaN values are ordered first.
 func KeysSortedByValue[M ~map[K]V, K comparable, V constraints.Ordered](m M) []K {
 	keys := make([]K, 0, len(m))
 	values := make([]V, 0, len(m))
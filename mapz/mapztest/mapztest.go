@@ -0,0 +1,215 @@
+// Package mapztest contains conformance tests for implementations of the mapz interfaces.
+// They can be used to test the types in mapz, as well as your own implementations.
+//
+// The tests are generic over the key and value types. They get their keys and values from generator functions:
+// key(i) must return different keys for different i, and value(i) must return different values for different i, none of which is the zero value.
+// Values are compared with reflect.DeepEqual.
+package mapztest
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/Jille/genericz/mapz"
+)
+
+// TestAppendOnlyMap tests that the map returned by newMap behaves like an AppendOnlyMap should. newMap must return a new, empty map every time it is called.
+func TestAppendOnlyMap[K comparable, V any](t *testing.T, newMap func() mapz.AppendOnlyMap[K, V], key func(i int) K, value func(i int) V) {
+	var zero V
+	a, b := key(0), key(1)
+	t.Run("Empty", func(t *testing.T) {
+		m := newMap()
+		if v, ok := m.Load(a); ok || !equal(v, zero) {
+			t.Errorf("Load(%v) on empty map = %v, %v; want %v, false", a, v, ok, zero)
+		}
+		if v := m.LoadOrZero(a); !equal(v, zero) {
+			t.Errorf("LoadOrZero(%v) on empty map = %v; want %v", a, v, zero)
+		}
+		m.Range(func(k K, v V) bool {
+			t.Errorf("Range on empty map yielded %v => %v", k, v)
+			return true
+		})
+	})
+	t.Run("LoadOrStore", func(t *testing.T) {
+		m := newMap()
+		v1, v2 := value(1), value(2)
+		if v, loaded := m.LoadOrStore(a, v1); loaded || !equal(v, v1) {
+			t.Errorf("LoadOrStore(%v, %v) = %v, %v; want %v, false", a, v1, v, loaded, v1)
+		}
+		if v, loaded := m.LoadOrStore(a, v2); !loaded || !equal(v, v1) {
+			t.Errorf("LoadOrStore(%v, %v) = %v, %v; want %v, true", a, v2, v, loaded, v1)
+		}
+		if v, ok := m.Load(a); !ok || !equal(v, v1) {
+			t.Errorf("Load(%v) = %v, %v; want %v, true", a, v, ok, v1)
+		}
+		if v := m.LoadOrZero(a); !equal(v, v1) {
+			t.Errorf("LoadOrZero(%v) = %v; want %v", a, v, v1)
+		}
+		if v, loaded := m.LoadOrStore(b, zero); loaded || !equal(v, zero) {
+			t.Errorf("LoadOrStore(%v, %v) = %v, %v; want %v, false", b, zero, v, loaded, zero)
+		}
+		if v, ok := m.Load(b); !ok || !equal(v, zero) {
+			t.Errorf("Load(%v) = %v, %v; want %v, true", b, v, ok, zero)
+		}
+	})
+	t.Run("Range", func(t *testing.T) {
+		m := newMap()
+		want := map[K]V{}
+		for i := 0; i < 100; i++ {
+			m.LoadOrStore(key(i), value(i))
+			want[key(i)] = value(i)
+		}
+		checkRange[K, V](t, m, want)
+	})
+}
+
+// TestMap tests that the map returned by newMap behaves like a Map should. It includes the tests of TestAppendOnlyMap. newMap must return a new, empty map every time it is called.
+func TestMap[K comparable, V any](t *testing.T, newMap func() mapz.Map[K, V], key func(i int) K, value func(i int) V) {
+	TestAppendOnlyMap(t, func() mapz.AppendOnlyMap[K, V] {
+		return newMap()
+	}, key, value)
+	var zero V
+	a, b, c := key(0), key(1), key(2)
+	t.Run("Store", func(t *testing.T) {
+		m := newMap()
+		m.Store(a, value(1))
+		m.Store(b, value(2))
+		m.Store(a, value(3))
+		if v, ok := m.Load(a); !ok || !equal(v, value(3)) {
+			t.Errorf("Load(%v) = %v, %v; want %v, true", a, v, ok, value(3))
+		}
+		if v, loaded := m.LoadOrStore(b, value(4)); !loaded || !equal(v, value(2)) {
+			t.Errorf("LoadOrStore(%v, %v) = %v, %v; want %v, true", b, value(4), v, loaded, value(2))
+		}
+		checkRange[K, V](t, m, map[K]V{a: value(3), b: value(2)})
+	})
+	t.Run("Delete", func(t *testing.T) {
+		m := newMap()
+		m.Delete(a)
+		if v, ok := m.LoadAndDelete(a); ok || !equal(v, zero) {
+			t.Errorf("LoadAndDelete(%v) on empty map = %v, %v; want %v, false", a, v, ok, zero)
+		}
+		m.Store(a, value(1))
+		m.Store(b, value(2))
+		m.Store(c, value(3))
+		m.Delete(a)
+		if _, ok := m.Load(a); ok {
+			t.Errorf("Load(%v) found a deleted key", a)
+		}
+		if v, ok := m.LoadAndDelete(b); !ok || !equal(v, value(2)) {
+			t.Errorf("LoadAndDelete(%v) = %v, %v; want %v, true", b, v, ok, value(2))
+		}
+		if _, ok := m.LoadAndDelete(b); ok {
+			t.Errorf("LoadAndDelete(%v) succeeded twice", b)
+		}
+		checkRange[K, V](t, m, map[K]V{c: value(3)})
+		if v, loaded := m.LoadOrStore(a, value(4)); loaded || !equal(v, value(4)) {
+			t.Errorf("LoadOrStore(%v, %v) after Delete = %v, %v; want %v, false", a, value(4), v, loaded, value(4))
+		}
+	})
+	t.Run("RangeCanModify", func(t *testing.T) {
+		m := newMap()
+		m.Store(a, value(1))
+		m.Store(b, value(2))
+		want := map[K]V{a: value(10), b: value(20)}
+		m.Range(func(k K, v V) bool {
+			m.Store(k, want[k])
+			return true
+		})
+		checkRange[K, V](t, m, want)
+	})
+}
+
+// TestConcurrentAppendOnlyMap tests that the map returned by newMap is safe for concurrent use. Run it with the race detector enabled. newMap must return a new, empty map every time it is called.
+func TestConcurrentAppendOnlyMap[K comparable, V any](t *testing.T, newMap func() mapz.AppendOnlyMap[K, V], key func(i int) K, value func(i int) V) {
+	m := newMap()
+	const goroutines = 8
+	results := make([][]V, goroutines)
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				v, _ := m.LoadOrStore(key(i), value(g))
+				results[g] = append(results[g], v)
+				m.Load(key(i + 1))
+			}
+			m.Range(func(k K, v V) bool {
+				return true
+			})
+		}(g)
+	}
+	wg.Wait()
+	for g := 1; g < goroutines; g++ {
+		for i := range results[g] {
+			if !equal(results[g][i], results[0][i]) {
+				t.Errorf("LoadOrStore(%v) returned different values to different goroutines: %v and %v", key(i), results[g][i], results[0][i])
+			}
+		}
+	}
+}
+
+// TestConcurrentMap tests that the map returned by newMap is safe for concurrent use. Run it with the race detector enabled. It includes the tests of TestConcurrentAppendOnlyMap. newMap must return a new, empty map every time it is called.
+func TestConcurrentMap[K comparable, V any](t *testing.T, newMap func() mapz.Map[K, V], key func(i int) K, value func(i int) V) {
+	TestConcurrentAppendOnlyMap(t, func() mapz.AppendOnlyMap[K, V] {
+		return newMap()
+	}, key, value)
+	m := newMap()
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				k := key(i % 10)
+				switch (g + i) % 4 {
+				case 0:
+					m.Store(k, value(i))
+				case 1:
+					m.Delete(k)
+				case 2:
+					m.LoadAndDelete(k)
+				case 3:
+					m.LoadOrStore(k, value(i))
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+func checkRange[K comparable, V any](t *testing.T, m mapz.Loader[K, V], want map[K]V) {
+	t.Helper()
+	got := map[K]V{}
+	m.Range(func(k K, v V) bool {
+		if _, dup := got[k]; dup {
+			t.Errorf("Range yielded %v twice", k)
+		}
+		got[k] = v
+		return true
+	})
+	if len(got) != len(want) {
+		t.Errorf("Range yielded %d entries; want %d", len(got), len(want))
+	}
+	for k, v := range want {
+		if g, ok := got[k]; !ok || !equal(g, v) {
+			t.Errorf("Range yielded %v => %v (found: %v); want %v", k, g, ok, v)
+		}
+	}
+	if len(want) > 1 {
+		n := 0
+		m.Range(func(k K, v V) bool {
+			n++
+			return false
+		})
+		if n != 1 {
+			t.Errorf("Range continued after f returned false")
+		}
+	}
+}
+
+func equal[V any](a, b V) bool {
+	return reflect.DeepEqual(a, b)
+}
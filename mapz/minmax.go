@@ -0,0 +1,146 @@
+package mapz
+
+import "golang.org/x/exp/constraints"
+
+// MinKeyOK returns the lowest key of the map m. The ok result is false if m is empty.
+// NaNs are less than any other value, so a NaN key is returned if there is one.
+func MinKeyOK[M ~map[K]V, K constraints.Ordered, V any](m M) (K, bool) {
+	return MinKeyFuncOK(m, compareOrdered[K])
+}
+
+// MaxKeyOK returns the highest key of the map m. The ok result is false if m is empty.
+// NaNs are less than any other value, so a NaN key is only returned if it is the only key.
+func MaxKeyOK[M ~map[K]V, K constraints.Ordered, V any](m M) (K, bool) {
+	return MaxKeyFuncOK(m, compareOrdered[K])
+}
+
+// MinKeyFunc returns the lowest key of the map m according to cmp. cmp should return a negative number if a < b, a positive number if a > b and 0 if they are equal, like cmp.Compare.
+// It panics when m is empty.
+func MinKeyFunc[M ~map[K]V, K comparable, V any](m M, cmp func(a, b K) int) K {
+	k, ok := MinKeyFuncOK(m, cmp)
+	if !ok {
+		panic("MinKeyFunc: map is empty")
+	}
+	return k
+}
+
+// MaxKeyFunc returns the highest key of the map m according to cmp. cmp should return a negative number if a < b, a positive number if a > b and 0 if they are equal, like cmp.Compare.
+// It panics when m is empty.
+func MaxKeyFunc[M ~map[K]V, K comparable, V any](m M, cmp func(a, b K) int) K {
+	k, ok := MaxKeyFuncOK(m, cmp)
+	if !ok {
+		panic("MaxKeyFunc: map is empty")
+	}
+	return k
+}
+
+// MinKeyFuncOK is like MinKeyFunc, but returns false instead of panicking if m is empty.
+func MinKeyFuncOK[M ~map[K]V, K comparable, V any](m M, cmp func(a, b K) int) (best K, ok bool) {
+	for k := range m {
+		if !ok || cmp(k, best) < 0 {
+			best, ok = k, true
+		}
+	}
+	return best, ok
+}
+
+// MaxKeyFuncOK is like MaxKeyFunc, but returns false instead of panicking if m is empty.
+func MaxKeyFuncOK[M ~map[K]V, K comparable, V any](m M, cmp func(a, b K) int) (best K, ok bool) {
+	for k := range m {
+		if !ok || cmp(k, best) > 0 {
+			best, ok = k, true
+		}
+	}
+	return best, ok
+}
+
+// MinBy returns the entry of m with the lowest value according to cmp. The ok result is false if m is empty.
+// If multiple entries have the lowest value, it's unspecified which one is returned.
+func MinBy[M ~map[K]V, K comparable, V any](m M, cmp func(a, b V) int) (key K, value V, ok bool) {
+	for k, v := range m {
+		if !ok || cmp(v, value) < 0 {
+			key, value, ok = k, v, true
+		}
+	}
+	return key, value, ok
+}
+
+// MaxBy returns the entry of m with the highest value according to cmp. The ok result is false if m is empty.
+// If multiple entries have the highest value, it's unspecified which one is returned.
+func MaxBy[M ~map[K]V, K comparable, V any](m M, cmp func(a, b V) int) (key K, value V, ok bool) {
+	for k, v := range m {
+		if !ok || cmp(v, value) > 0 {
+			key, value, ok = k, v, true
+		}
+	}
+	return key, value, ok
+}
+
+// TopKByValue returns the keys of the n entries with the highest values, ordered from highest to lowest value.
+// If m has fewer than n entries, all keys are returned. Ties are broken in unspecified order.
+// NaNs are less than any other value, so keys with a NaN value come last.
+// It takes O(len(m) log n) time, which is cheaper than KeysSortedByValue if n is small.
+func TopKByValue[M ~map[K]V, K comparable, V constraints.Ordered](m M, n int) []K {
+	return topK(m, n, compareOrdered[V])
+}
+
+// BottomKByValue returns the keys of the n entries with the lowest values, ordered from lowest to highest value.
+// If m has fewer than n entries, all keys are returned. Ties are broken in unspecified order.
+// NaNs are less than any other value, so keys with a NaN value come first.
+// It takes O(len(m) log n) time, which is cheaper than KeysSortedByValue if n is small.
+func BottomKByValue[M ~map[K]V, K comparable, V constraints.Ordered](m M, n int) []K {
+	return topK(m, n, func(a, b V) int {
+		return compareOrdered(b, a)
+	})
+}
+
+// topK returns the keys of the n highest values according to cmp, from highest to lowest.
+func topK[M ~map[K]V, K comparable, V any](m M, n int, cmp func(a, b V) int) []K {
+	if n > len(m) {
+		n = len(m)
+	}
+	if n <= 0 {
+		return nil
+	}
+	// h is a min-heap of the n highest values seen so far, so h[0] is the one to evict.
+	h := make([]Pair[K, V], 0, n)
+	less := func(i, j int) bool {
+		return cmp(h[i].Value, h[j].Value) < 0
+	}
+	down := func(i int) {
+		for {
+			c := 2*i + 1
+			if c >= len(h) {
+				return
+			}
+			if c+1 < len(h) && less(c+1, c) {
+				c++
+			}
+			if !less(c, i) {
+				return
+			}
+			h[i], h[c] = h[c], h[i]
+			i = c
+		}
+	}
+	for k, v := range m {
+		if len(h) < n {
+			h = append(h, Pair[K, V]{k, v})
+			for i := len(h) - 1; i > 0 && less(i, (i-1)/2); i = (i - 1) / 2 {
+				h[i], h[(i-1)/2] = h[(i-1)/2], h[i]
+			}
+		} else if cmp(v, h[0].Value) > 0 {
+			h[0] = Pair[K, V]{k, v}
+			down(0)
+		}
+	}
+	// Pop the lowest values and put them at the end.
+	ret := make([]K, len(h))
+	for i := len(ret) - 1; i >= 0; i-- {
+		ret[i] = h[0].Key
+		h[0] = h[len(h)-1]
+		h = h[:len(h)-1]
+		down(0)
+	}
+	return ret
+}
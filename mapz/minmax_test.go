@@ -0,0 +1,104 @@
+package mapz
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMinMaxKeyFunc(t *testing.T) {
+	m := map[string]int{"b": 1, "A": 2, "c": 3}
+	caseInsensitive := func(a, b string) int {
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	}
+	if k := MinKeyFunc(m, caseInsensitive); k != "A" {
+		t.Errorf("MinKeyFunc() = %q; want A", k)
+	}
+	if k := MaxKeyFunc(m, caseInsensitive); k != "c" {
+		t.Errorf("MaxKeyFunc() = %q; want c", k)
+	}
+	if k, ok := MinKeyOK(m); !ok || k != "A" {
+		t.Errorf("MinKeyOK() = %q, %v; want A, true", k, ok)
+	}
+	if k, ok := MaxKeyOK(m); !ok || k != "c" {
+		t.Errorf("MaxKeyOK() = %q, %v; want c, true", k, ok)
+	}
+	if _, ok := MinKeyOK(map[string]int{}); ok {
+		t.Errorf("MinKeyOK() on an empty map succeeded")
+	}
+	if _, ok := MaxKeyFuncOK(map[string]int{}, caseInsensitive); ok {
+		t.Errorf("MaxKeyFuncOK() on an empty map succeeded")
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("MinKeyFunc() on an empty map didn't panic")
+			}
+		}()
+		MinKeyFunc(map[string]int{}, caseInsensitive)
+	}()
+
+	f := map[float64]bool{1: true, math.NaN(): true, -1: true}
+	if k, _ := MinKeyOK(f); !math.IsNaN(k) {
+		t.Errorf("MinKeyOK() = %v; want NaN", k)
+	}
+	if k, _ := MaxKeyOK(f); k != 1 {
+		t.Errorf("MaxKeyOK() = %v; want 1", k)
+	}
+}
+
+func TestMinMaxBy(t *testing.T) {
+	m := map[string]string{"a": "banana", "b": "fig", "c": "cherry"}
+	byLen := func(a, b string) int {
+		return len(a) - len(b)
+	}
+	if k, v, ok := MinBy(m, byLen); !ok || k != "b" || v != "fig" {
+		t.Errorf("MinBy(len) = %q, %q, %v; want b, fig, true", k, v, ok)
+	}
+	if k, _, ok := MaxBy(map[string]string{"a": "x", "b": "xyz"}, byLen); !ok || k != "b" {
+		t.Errorf("MaxBy(len) = %q, %v; want b, true", k, ok)
+	}
+	if _, _, ok := MaxBy(map[string]string{}, byLen); ok {
+		t.Errorf("MaxBy() on an empty map succeeded")
+	}
+}
+
+func TestTopKByValue(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	m := map[int]int{}
+	for i := 0; i < 1000; i++ {
+		m[i] = rnd.Int()
+	}
+	sorted := KeysSortedByValue(m)
+	for _, n := range []int{0, 1, 10, 999, 1000, 2000} {
+		want := sorted
+		if n < len(want) {
+			want = want[:n]
+		}
+		if n == 0 {
+			want = nil
+		}
+		if got := BottomKByValue(m, n); !reflect.DeepEqual(got, want) {
+			t.Errorf("BottomKByValue(%d) = %v; want %v", n, got, want)
+		}
+		top := TopKByValue(m, n)
+		for i, k := range top {
+			if w := sorted[len(sorted)-1-i]; k != w {
+				t.Fatalf("TopKByValue(%d)[%d] = %d; want %d", n, i, k, w)
+			}
+		}
+		if len(top) != len(want) {
+			t.Errorf("TopKByValue(%d) returned %d keys; want %d", n, len(top), len(want))
+		}
+	}
+
+	f := map[string]float64{"nan": math.NaN(), "one": 1, "inf": math.Inf(1), "min": math.Inf(-1)}
+	if got, want := TopKByValue(f, 4), []string{"inf", "one", "min", "nan"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("TopKByValue() = %v; want %v", got, want)
+	}
+	if got, want := BottomKByValue(f, 2), []string{"nan", "min"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("BottomKByValue() = %v; want %v", got, want)
+	}
+}
@@ -0,0 +1,276 @@
+package mapz
+
+// MultiMap is a map from keys to lists of values. Values are kept in the order they were added, and the same value can be added more than once. Use SetMultiMap if values should be unique.
+// Keys are removed when their last value is removed, so Len only counts keys with at least one value.
+// It isn't safe for concurrent use. Use MutexMultiMap if you need that.
+// The zero value is valid.
+type MultiMap[K comparable, V any] struct {
+	m map[K][]V
+	n int
+}
+
+// Add appends value to the values for key.
+func (m *MultiMap[K, V]) Add(key K, value V) {
+	if m.m == nil {
+		m.m = map[K][]V{}
+	}
+	m.m[key] = append(m.m[key], value)
+	m.n++
+}
+
+// AddAll appends values to the values for key.
+func (m *MultiMap[K, V]) AddAll(key K, values ...V) {
+	if len(values) == 0 {
+		return
+	}
+	if m.m == nil {
+		m.m = map[K][]V{}
+	}
+	m.m[key] = append(m.m[key], values...)
+	m.n += len(values)
+}
+
+// Get returns the values for key in the order they were added, or nil if there are none.
+// The returned slice must not be modified, but later changes to the map don't affect it.
+func (m *MultiMap[K, V]) Get(key K) []V {
+	vs := m.m[key]
+	// Removing values always creates a new slice, so only appends can touch the backing array, and those won't go into our part of it.
+	return vs[:len(vs):len(vs)]
+}
+
+// ContainsKey returns whether key has any values.
+func (m *MultiMap[K, V]) ContainsKey(key K) bool {
+	_, ok := m.m[key]
+	return ok
+}
+
+// RemoveFunc removes the values for key for which f returns true. It returns the number of removed values.
+func (m *MultiMap[K, V]) RemoveFunc(key K, f func(value V) bool) int {
+	vs := m.m[key]
+	var keep []V
+	for i, v := range vs {
+		if f(v) {
+			if keep == nil {
+				keep = make([]V, i, len(vs)-1)
+				copy(keep, vs)
+			}
+		} else if keep != nil {
+			keep = append(keep, v)
+		}
+	}
+	if keep == nil {
+		return 0
+	}
+	removed := len(vs) - len(keep)
+	m.n -= removed
+	if len(keep) == 0 {
+		delete(m.m, key)
+	} else {
+		m.m[key] = keep
+	}
+	return removed
+}
+
+// RemoveAll removes all values for key and returns them.
+func (m *MultiMap[K, V]) RemoveAll(key K) []V {
+	vs := m.m[key]
+	delete(m.m, key)
+	m.n -= len(vs)
+	return vs
+}
+
+// Len returns the number of keys in the map.
+func (m *MultiMap[K, V]) Len() int {
+	return len(m.m)
+}
+
+// NumValues returns the total number of values in the map.
+func (m *MultiMap[K, V]) NumValues() int {
+	return m.n
+}
+
+// Range calls f sequentially for each key and value present in the map. The values of a key are visited in the order they were added. If f returns false, range stops the iteration.
+// f may modify the map. Keys are visited with the values they had when Range got to them.
+func (m *MultiMap[K, V]) Range(f func(key K, value V) bool) {
+	for k, vs := range m.m {
+		for _, v := range vs {
+			if !f(k, v) {
+				return
+			}
+		}
+	}
+}
+
+// RangeKeys calls f sequentially for each key present in the map. If f returns false, range stops the iteration.
+func (m *MultiMap[K, V]) RangeKeys(f func(key K) bool) {
+	for k := range m.m {
+		if !f(k) {
+			return
+		}
+	}
+}
+
+// MultiMapRemove removes the first occurrence of value from the values for key. It returns whether value was found.
+//
+// This is a function rather than a method because Go 1.18 doesn't allow restricting a method's type parameters more than the base type (yet?).
+func MultiMapRemove[K, V comparable](m *MultiMap[K, V], key K, value V) bool {
+	found := false
+	return m.RemoveFunc(key, func(v V) bool {
+		if found || v != value {
+			return false
+		}
+		found = true
+		return true
+	}) > 0
+}
+
+// MultiMapContains returns whether value is one of the values for key.
+//
+// This is a function rather than a method because Go 1.18 doesn't allow restricting a method's type parameters more than the base type (yet?).
+func MultiMapContains[K, V comparable](m *MultiMap[K, V], key K, value V) bool {
+	for _, v := range m.m[key] {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// MultiMapInvert returns a new MultiMap that maps every value to the keys it belongs to. A key is listed as often as the value occurs for it. The order of the keys of a value is unspecified.
+//
+// This is a function rather than a method because Go 1.18 doesn't allow restricting a method's type parameters more than the base type (yet?).
+func MultiMapInvert[K, V comparable](m *MultiMap[K, V]) *MultiMap[V, K] {
+	ret := &MultiMap[V, K]{}
+	for k, vs := range m.m {
+		for _, v := range vs {
+			ret.Add(v, k)
+		}
+	}
+	return ret
+}
+
+// SetMultiMap is a map from keys to sets of values. Adding a value that's already present for the key is a no-op.
+// Keys are removed when their last value is removed, so Len only counts keys with at least one value.
+// It isn't safe for concurrent use. Use MutexSetMultiMap if you need that.
+// The zero value is valid.
+type SetMultiMap[K, V comparable] struct {
+	m map[K]map[V]struct{}
+	n int
+}
+
+// Add adds value to the values for key. It returns false if it was already present.
+func (m *SetMultiMap[K, V]) Add(key K, value V) bool {
+	if m.m == nil {
+		m.m = map[K]map[V]struct{}{}
+	}
+	s, ok := m.m[key]
+	if !ok {
+		s = map[V]struct{}{}
+		m.m[key] = s
+	} else if _, dup := s[value]; dup {
+		return false
+	}
+	s[value] = struct{}{}
+	m.n++
+	return true
+}
+
+// AddAll adds values to the values for key. It returns the number of values that weren't present yet.
+func (m *SetMultiMap[K, V]) AddAll(key K, values ...V) int {
+	added := 0
+	for _, v := range values {
+		if m.Add(key, v) {
+			added++
+		}
+	}
+	return added
+}
+
+// Get returns the values for key in unspecified order, or nil if there are none.
+func (m *SetMultiMap[K, V]) Get(key K) []V {
+	s := m.m[key]
+	if len(s) == 0 {
+		return nil
+	}
+	ret := make([]V, 0, len(s))
+	for v := range s {
+		ret = append(ret, v)
+	}
+	return ret
+}
+
+// Contains returns whether value is one of the values for key.
+func (m *SetMultiMap[K, V]) Contains(key K, value V) bool {
+	_, ok := m.m[key][value]
+	return ok
+}
+
+// ContainsKey returns whether key has any values.
+func (m *SetMultiMap[K, V]) ContainsKey(key K) bool {
+	_, ok := m.m[key]
+	return ok
+}
+
+// Remove removes value from the values for key. It returns whether it was present.
+func (m *SetMultiMap[K, V]) Remove(key K, value V) bool {
+	s := m.m[key]
+	if _, ok := s[value]; !ok {
+		return false
+	}
+	delete(s, value)
+	if len(s) == 0 {
+		delete(m.m, key)
+	}
+	m.n--
+	return true
+}
+
+// RemoveAll removes all values for key and returns them in unspecified order.
+func (m *SetMultiMap[K, V]) RemoveAll(key K) []V {
+	ret := m.Get(key)
+	delete(m.m, key)
+	m.n -= len(ret)
+	return ret
+}
+
+// Len returns the number of keys in the map.
+func (m *SetMultiMap[K, V]) Len() int {
+	return len(m.m)
+}
+
+// NumValues returns the total number of values in the map.
+func (m *SetMultiMap[K, V]) NumValues() int {
+	return m.n
+}
+
+// Range calls f sequentially for each key and value present in the map. If f returns false, range stops the iteration.
+// f may modify the map, with the same guarantees as ranging over a regular map.
+func (m *SetMultiMap[K, V]) Range(f func(key K, value V) bool) {
+	for k, s := range m.m {
+		for v := range s {
+			if !f(k, v) {
+				return
+			}
+		}
+	}
+}
+
+// RangeKeys calls f sequentially for each key present in the map. If f returns false, range stops the iteration.
+func (m *SetMultiMap[K, V]) RangeKeys(f func(key K) bool) {
+	for k := range m.m {
+		if !f(k) {
+			return
+		}
+	}
+}
+
+// Invert returns a new SetMultiMap that maps every value to the keys it belongs to.
+func (m *SetMultiMap[K, V]) Invert() *SetMultiMap[V, K] {
+	ret := &SetMultiMap[V, K]{}
+	for k, s := range m.m {
+		for v := range s {
+			ret.Add(v, k)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,153 @@
+package mapz
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestMultiMap(t *testing.T) {
+	var m MultiMap[string, int]
+	if got := m.Get("a"); got != nil {
+		t.Errorf("Get(a) on an empty map = %v; want nil", got)
+	}
+	m.Add("a", 1)
+	m.AddAll("a", 2, 1, 3)
+	m.AddAll("b")
+	m.Add("b", 4)
+	if got, want := m.Get("a"), []int{1, 2, 1, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Get(a) = %v; want %v", got, want)
+	}
+	if m.Len() != 2 || m.NumValues() != 5 {
+		t.Errorf("Len(), NumValues() = %d, %d; want 2, 5", m.Len(), m.NumValues())
+	}
+	if !MultiMapContains(&m, "a", 3) || MultiMapContains(&m, "b", 3) {
+		t.Errorf("MultiMapContains returned the wrong result")
+	}
+
+	got := m.Get("a")
+	if !MultiMapRemove(&m, "a", 1) {
+		t.Errorf("MultiMapRemove(a, 1) = false; want true")
+	}
+	m.Add("a", 5)
+	if want := []int{1, 2, 1, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("slice returned by Get changed to %v; want %v", got, want)
+	}
+	if got, want := m.Get("a"), []int{2, 1, 3, 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Get(a) = %v; want %v", got, want)
+	}
+	if n := m.RemoveFunc("a", func(v int) bool { return v%2 == 1 }); n != 3 {
+		t.Errorf("RemoveFunc(a, odd) = %d; want 3", n)
+	}
+	if got, want := m.RemoveAll("a"), []int{2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RemoveAll(a) = %v; want %v", got, want)
+	}
+	if m.ContainsKey("a") || m.Len() != 1 || m.NumValues() != 1 {
+		t.Errorf("after removing all values of a: ContainsKey(a) = %v, Len() = %d, NumValues() = %d", m.ContainsKey("a"), m.Len(), m.NumValues())
+	}
+	if MultiMapRemove(&m, "b", 5) {
+		t.Errorf("MultiMapRemove(b, 5) = true; want false")
+	}
+	if !MultiMapRemove(&m, "b", 4) || m.Len() != 0 || m.NumValues() != 0 {
+		t.Errorf("map isn't empty after removing the last value")
+	}
+}
+
+func TestMultiMapInvert(t *testing.T) {
+	var m MultiMap[string, int]
+	m.AddAll("a", 1, 2, 2)
+	m.AddAll("b", 2, 3)
+	inv := MultiMapInvert(&m)
+	want := map[int][]string{1: {"a"}, 2: {"a", "a", "b"}, 3: {"b"}}
+	if inv.Len() != len(want) || inv.NumValues() != m.NumValues() {
+		t.Errorf("MultiMapInvert has Len() %d and NumValues() %d; want %d and %d", inv.Len(), inv.NumValues(), len(want), m.NumValues())
+	}
+	for v, keys := range want {
+		got := append([]string(nil), inv.Get(v)...)
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, keys) {
+			t.Errorf("MultiMapInvert().Get(%d) = %v; want %v", v, got, keys)
+		}
+	}
+}
+
+func TestSetMultiMap(t *testing.T) {
+	var m SetMultiMap[string, int]
+	if !m.Add("a", 1) || m.Add("a", 1) {
+		t.Errorf("Add didn't report correctly whether the value was new")
+	}
+	if n := m.AddAll("a", 1, 2, 3, 3); n != 2 {
+		t.Errorf("AddAll(a, 1, 2, 3, 3) = %d; want 2", n)
+	}
+	m.Add("b", 1)
+	if m.Len() != 2 || m.NumValues() != 4 {
+		t.Errorf("Len(), NumValues() = %d, %d; want 2, 4", m.Len(), m.NumValues())
+	}
+	got := m.Get("a")
+	sort.Ints(got)
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Get(a) = %v; want %v", got, want)
+	}
+	if !m.Contains("a", 2) || m.Contains("b", 2) || m.Contains("c", 2) {
+		t.Errorf("Contains returned the wrong result")
+	}
+	if !m.Remove("a", 2) || m.Remove("a", 2) {
+		t.Errorf("Remove didn't report correctly whether the value was present")
+	}
+
+	inv := m.Invert()
+	if got := inv.Get(1); len(got) != 2 || !inv.Contains(1, "a") || !inv.Contains(1, "b") {
+		t.Errorf("Invert().Get(1) = %v; want [a b]", got)
+	}
+	if !inv.Contains(3, "a") || inv.Len() != 2 || inv.NumValues() != 3 {
+		t.Errorf("Invert() has Len() %d and NumValues() %d; want 2 and 3", inv.Len(), inv.NumValues())
+	}
+
+	if got := m.RemoveAll("a"); len(got) != 2 {
+		t.Errorf("RemoveAll(a) = %v; want 2 values", got)
+	}
+	if !m.Remove("b", 1) || m.Len() != 0 || m.NumValues() != 0 || m.ContainsKey("b") {
+		t.Errorf("map isn't empty after removing the last value")
+	}
+}
+
+func TestMutexMultiMapConcurrency(t *testing.T) {
+	var m MutexMultiMap[int, string]
+	var s MutexSetMultiMap[int, string]
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				v := strconv.Itoa(w)
+				m.Add(i%10, v)
+				s.Add(i%10, v)
+				m.Range(func(k int, v string) bool {
+					return k != 5
+				})
+				s.Range(func(k int, v string) bool {
+					return true
+				})
+				if i%3 == 0 {
+					MutexMultiMapRemove(&m, i%10, v)
+					s.Remove(i%10, v)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	if m.NumValues() != 4*(1000-334) {
+		t.Errorf("MutexMultiMap.NumValues() = %d; want %d", m.NumValues(), 4*(1000-334))
+	}
+	sum := 0
+	s.RangeKeys(func(k int) bool {
+		sum += len(s.Get(k))
+		return true
+	})
+	if sum != s.NumValues() {
+		t.Errorf("MutexSetMultiMap has %d values in %d keys, but NumValues() = %d", sum, s.Len(), s.NumValues())
+	}
+}
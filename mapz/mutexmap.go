@@ -1,12 +1,23 @@
 package mapz
 
-import "sync"
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"golang.org/x/exp/maps"
+)
 
 // MutexMap is a map protected with a mutex. Its interface closely resembles sync.Map.
 // The zero value is valid.
+//
+// MutexMap implements json.Marshaler with a pointer receiver, because marshaling takes the lock. encoding/json only uses it if the MutexMap is addressable, so marshal a pointer to a struct containing a MutexMap. Marshaling such a struct by value would copy the lock (go vet warns about that) and encode the fields L and M instead.
 type MutexMap[K comparable, V any] struct {
 	L sync.Mutex
 	M map[K]V
+
+	// calls holds the in-flight LoadOrCompute calls. It's protected by L and created when first needed.
+	calls map[K]*computeCall[V]
 }
 
 // Load returns the value stored in the map for a key. The ok result indicates whether value was found in the map.
@@ -67,6 +78,41 @@ func (m *MutexMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
 	return value, false
 }
 
+// LoadOrCompute returns the existing value for the key if present. Otherwise, it calls fn and stores and returns its result.
+// fn runs at most once per key at a time: concurrent callers for the same key wait for the running fn and get its result.
+// If fn returns an error, nothing is stored and the error is returned to all callers waiting for it. The next call will try again.
+// If fn panics, the panic is propagated to the caller that ran it and waiting callers get a *ComputePanicError.
+//
+// fn is called without holding the lock, so it may call any method on m.
+func (m *MutexMap[K, V]) LoadOrCompute(key K, fn func() (V, error)) (V, error) {
+	return loadOrCompute(&m.L, &m.calls, key, m.loadLocked, m.computed, fn)
+}
+
+// LoadOrComputeContext is like LoadOrCompute, but callers waiting for the computation return ctx.Err() when their ctx is cancelled.
+// fn runs in its own goroutine, so it keeps going for the remaining callers when the caller that started it gives up.
+// The context passed to fn carries the values of the ctx of the caller that started it, and is only cancelled once all callers waiting for it have given up.
+// If fn panics, the caller that started it panics with a *ComputePanicError holding the panic value and stack trace, and the other callers waiting for it get that error.
+func (m *MutexMap[K, V]) LoadOrComputeContext(ctx context.Context, key K, fn func(ctx context.Context) (V, error)) (V, error) {
+	return loadOrComputeContext(ctx, &m.L, &m.calls, key, m.loadLocked, m.computed, fn)
+}
+
+func (m *MutexMap[K, V]) loadLocked(key K) (V, bool) {
+	v, ok := m.M[key]
+	return v, ok
+}
+
+// computed stores the result of LoadOrCompute unless a value was stored in the meantime. m.L must be held.
+func (m *MutexMap[K, V]) computed(key K, value V) V {
+	if v, ok := m.M[key]; ok {
+		return v
+	}
+	if m.M == nil {
+		m.M = map[K]V{}
+	}
+	m.M[key] = value
+	return value
+}
+
 // Swap swaps the value for a key and returns the previous value if any. The loaded result reports whether the key was present.
 func (m *MutexMap[K, V]) Swap(key K, value V) (previous V, loaded bool) {
 	m.L.Lock()
@@ -141,6 +187,70 @@ func MutexMapCompareAndSwap[K, V comparable](m *MutexMap[K, V], key K, old, new
 	return false
 }
 
+// StoreAll sets the values for all keys in entries. The lock is taken only once.
+func (m *MutexMap[K, V]) StoreAll(entries map[K]V) {
+	m.L.Lock()
+	defer m.L.Unlock()
+	if m.M == nil {
+		m.M = make(map[K]V, len(entries))
+	}
+	for k, v := range entries {
+		m.M[k] = v
+	}
+}
+
+// LoadMany returns the values stored in the map for the given keys. Keys that aren't present are omitted from the result. The lock is taken only once.
+func (m *MutexMap[K, V]) LoadMany(keys ...K) map[K]V {
+	m.L.Lock()
+	defer m.L.Unlock()
+	ret := make(map[K]V, len(keys))
+	for _, k := range keys {
+		if v, ok := m.M[k]; ok {
+			ret[k] = v
+		}
+	}
+	return ret
+}
+
+// DeleteMany deletes the values for the given keys. The lock is taken only once.
+func (m *MutexMap[K, V]) DeleteMany(keys ...K) {
+	m.L.Lock()
+	defer m.L.Unlock()
+	for _, k := range keys {
+		delete(m.M, k)
+	}
+}
+
+// LoadAndDeleteAll deletes all values from the map and returns them. The lock is taken only once.
+// The returned map is a copy, because a concurrent Range might still be iterating over the old one.
+func (m *MutexMap[K, V]) LoadAndDeleteAll() map[K]V {
+	m.L.Lock()
+	defer m.L.Unlock()
+	ret := maps.Clone(m.M)
+	m.M = nil
+	return ret
+}
+
+// MarshalJSON encodes the map as a JSON object, with the same rules as encoding/json uses for a map[K]V. Keys are sorted, integer keys by their value.
+// The method has a pointer receiver, see MutexMap.
+func (m *MutexMap[K, V]) MarshalJSON() ([]byte, error) {
+	m.L.Lock()
+	defer m.L.Unlock()
+	return marshalSortedJSON(m.M)
+}
+
+// UnmarshalJSON replaces the contents of the map with the given JSON object.
+func (m *MutexMap[K, V]) UnmarshalJSON(data []byte) error {
+	var n map[K]V
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	m.L.Lock()
+	defer m.L.Unlock()
+	m.M = n
+	return nil
+}
+
 // WithLock calls f while holding the lock. f can manipulate the given map at will, but can't use m's regular functions because it's already holding the lock itself.
 func (m *MutexMap[K, V]) WithLock(f func(m map[K]V)) {
 	m.L.Lock()
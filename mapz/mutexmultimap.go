@@ -0,0 +1,256 @@
+package mapz
+
+import "sync"
+
+// MutexMultiMap is a MultiMap protected with a mutex.
+// The zero value is valid.
+type MutexMultiMap[K comparable, V any] struct {
+	L sync.Mutex
+	M MultiMap[K, V]
+}
+
+// Add appends value to the values for key.
+func (m *MutexMultiMap[K, V]) Add(key K, value V) {
+	m.L.Lock()
+	defer m.L.Unlock()
+	m.M.Add(key, value)
+}
+
+// AddAll appends values to the values for key.
+func (m *MutexMultiMap[K, V]) AddAll(key K, values ...V) {
+	m.L.Lock()
+	defer m.L.Unlock()
+	m.M.AddAll(key, values...)
+}
+
+// Get returns the values for key in the order they were added, or nil if there are none.
+// The returned slice must not be modified, but later changes to the map don't affect it.
+func (m *MutexMultiMap[K, V]) Get(key K) []V {
+	m.L.Lock()
+	defer m.L.Unlock()
+	return m.M.Get(key)
+}
+
+// ContainsKey returns whether key has any values.
+func (m *MutexMultiMap[K, V]) ContainsKey(key K) bool {
+	m.L.Lock()
+	defer m.L.Unlock()
+	return m.M.ContainsKey(key)
+}
+
+// RemoveFunc removes the values for key for which f returns true. It returns the number of removed values.
+// f is called with the lock held, so it must not call any methods on m.
+func (m *MutexMultiMap[K, V]) RemoveFunc(key K, f func(value V) bool) int {
+	m.L.Lock()
+	defer m.L.Unlock()
+	return m.M.RemoveFunc(key, f)
+}
+
+// RemoveAll removes all values for key and returns them.
+func (m *MutexMultiMap[K, V]) RemoveAll(key K) []V {
+	m.L.Lock()
+	defer m.L.Unlock()
+	return m.M.RemoveAll(key)
+}
+
+// Len returns the number of keys in the map.
+func (m *MutexMultiMap[K, V]) Len() int {
+	m.L.Lock()
+	defer m.L.Unlock()
+	return m.M.Len()
+}
+
+// NumValues returns the total number of values in the map.
+func (m *MutexMultiMap[K, V]) NumValues() int {
+	m.L.Lock()
+	defer m.L.Unlock()
+	return m.M.NumValues()
+}
+
+// WithLock calls f while holding the lock. f can manipulate the given map at will, but can't use m's regular functions because it's already holding the lock itself.
+func (m *MutexMultiMap[K, V]) WithLock(f func(m *MultiMap[K, V])) {
+	m.L.Lock()
+	defer m.L.Unlock()
+	f(&m.M)
+}
+
+// Range calls f sequentially for each key and value present in the map. The values of a key are visited in the order they were added. If f returns false, range stops the iteration.
+//
+// Range does not block other methods on the receiver; even f itself may call any method on m. Keys are visited with the values they had when Range got to them.
+//
+// Range repeatedly picks up and drops the mutex so f() won't be called with the mutex held. Use WithLock if you need more performance at the cost of blocking other users.
+func (m *MutexMultiMap[K, V]) Range(f func(key K, value V) bool) {
+	m.L.Lock()
+	for k, vs := range m.M.m {
+		m.L.Unlock()
+		// vs can be read without the lock, see MultiMap.Get.
+		for _, v := range vs {
+			if !f(k, v) {
+				return
+			}
+		}
+		m.L.Lock()
+	}
+	m.L.Unlock()
+}
+
+// RangeKeys calls f sequentially for each key present in the map. If f returns false, range stops the iteration.
+//
+// Like Range, it doesn't hold the mutex while calling f.
+func (m *MutexMultiMap[K, V]) RangeKeys(f func(key K) bool) {
+	m.L.Lock()
+	for k := range m.M.m {
+		m.L.Unlock()
+		if !f(k) {
+			return
+		}
+		m.L.Lock()
+	}
+	m.L.Unlock()
+}
+
+// MutexMultiMapRemove removes the first occurrence of value from the values for key. It returns whether value was found.
+//
+// This is a function rather than a method because Go 1.18 doesn't allow restricting a method's type parameters more than the base type (yet?).
+func MutexMultiMapRemove[K, V comparable](m *MutexMultiMap[K, V], key K, value V) bool {
+	m.L.Lock()
+	defer m.L.Unlock()
+	return MultiMapRemove(&m.M, key, value)
+}
+
+// MutexMultiMapContains returns whether value is one of the values for key.
+//
+// This is a function rather than a method because Go 1.18 doesn't allow restricting a method's type parameters more than the base type (yet?).
+func MutexMultiMapContains[K, V comparable](m *MutexMultiMap[K, V], key K, value V) bool {
+	m.L.Lock()
+	defer m.L.Unlock()
+	return MultiMapContains(&m.M, key, value)
+}
+
+// MutexMultiMapInvert returns a new, unsynchronized MultiMap that maps every value to the keys it belongs to.
+//
+// This is a function rather than a method because Go 1.18 doesn't allow restricting a method's type parameters more than the base type (yet?).
+func MutexMultiMapInvert[K, V comparable](m *MutexMultiMap[K, V]) *MultiMap[V, K] {
+	m.L.Lock()
+	defer m.L.Unlock()
+	return MultiMapInvert(&m.M)
+}
+
+// MutexSetMultiMap is a SetMultiMap protected with a mutex.
+// The zero value is valid.
+type MutexSetMultiMap[K, V comparable] struct {
+	L sync.Mutex
+	M SetMultiMap[K, V]
+}
+
+// Add adds value to the values for key. It returns false if it was already present.
+func (m *MutexSetMultiMap[K, V]) Add(key K, value V) bool {
+	m.L.Lock()
+	defer m.L.Unlock()
+	return m.M.Add(key, value)
+}
+
+// AddAll adds values to the values for key. It returns the number of values that weren't present yet.
+func (m *MutexSetMultiMap[K, V]) AddAll(key K, values ...V) int {
+	m.L.Lock()
+	defer m.L.Unlock()
+	return m.M.AddAll(key, values...)
+}
+
+// Get returns the values for key in unspecified order, or nil if there are none.
+func (m *MutexSetMultiMap[K, V]) Get(key K) []V {
+	m.L.Lock()
+	defer m.L.Unlock()
+	return m.M.Get(key)
+}
+
+// Contains returns whether value is one of the values for key.
+func (m *MutexSetMultiMap[K, V]) Contains(key K, value V) bool {
+	m.L.Lock()
+	defer m.L.Unlock()
+	return m.M.Contains(key, value)
+}
+
+// ContainsKey returns whether key has any values.
+func (m *MutexSetMultiMap[K, V]) ContainsKey(key K) bool {
+	m.L.Lock()
+	defer m.L.Unlock()
+	return m.M.ContainsKey(key)
+}
+
+// Remove removes value from the values for key. It returns whether it was present.
+func (m *MutexSetMultiMap[K, V]) Remove(key K, value V) bool {
+	m.L.Lock()
+	defer m.L.Unlock()
+	return m.M.Remove(key, value)
+}
+
+// RemoveAll removes all values for key and returns them in unspecified order.
+func (m *MutexSetMultiMap[K, V]) RemoveAll(key K) []V {
+	m.L.Lock()
+	defer m.L.Unlock()
+	return m.M.RemoveAll(key)
+}
+
+// Len returns the number of keys in the map.
+func (m *MutexSetMultiMap[K, V]) Len() int {
+	m.L.Lock()
+	defer m.L.Unlock()
+	return m.M.Len()
+}
+
+// NumValues returns the total number of values in the map.
+func (m *MutexSetMultiMap[K, V]) NumValues() int {
+	m.L.Lock()
+	defer m.L.Unlock()
+	return m.M.NumValues()
+}
+
+// WithLock calls f while holding the lock. f can manipulate the given map at will, but can't use m's regular functions because it's already holding the lock itself.
+func (m *MutexSetMultiMap[K, V]) WithLock(f func(m *SetMultiMap[K, V])) {
+	m.L.Lock()
+	defer m.L.Unlock()
+	f(&m.M)
+}
+
+// Range calls f sequentially for each key and value present in the map. If f returns false, range stops the iteration.
+//
+// Range does not block other methods on the receiver; even f itself may call any method on m. Keys are visited with the values they had when Range got to them.
+//
+// Range repeatedly picks up and drops the mutex so f() won't be called with the mutex held. Use WithLock if you need more performance at the cost of blocking other users.
+func (m *MutexSetMultiMap[K, V]) Range(f func(key K, value V) bool) {
+	m.L.Lock()
+	for k := range m.M.m {
+		vs := m.M.Get(k)
+		m.L.Unlock()
+		for _, v := range vs {
+			if !f(k, v) {
+				return
+			}
+		}
+		m.L.Lock()
+	}
+	m.L.Unlock()
+}
+
+// RangeKeys calls f sequentially for each key present in the map. If f returns false, range stops the iteration.
+//
+// Like Range, it doesn't hold the mutex while calling f.
+func (m *MutexSetMultiMap[K, V]) RangeKeys(f func(key K) bool) {
+	m.L.Lock()
+	for k := range m.M.m {
+		m.L.Unlock()
+		if !f(k) {
+			return
+		}
+		m.L.Lock()
+	}
+	m.L.Unlock()
+}
+
+// Invert returns a new, unsynchronized SetMultiMap that maps every value to the keys it belongs to.
+func (m *MutexSetMultiMap[K, V]) Invert() *SetMultiMap[V, K] {
+	m.L.Lock()
+	defer m.L.Unlock()
+	return m.M.Invert()
+}
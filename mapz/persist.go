@@ -0,0 +1,260 @@
+package mapz
+
+import (
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// Format is the encoding used by Save.
+type Format byte
+
+const (
+	// FormatGob encodes entries with encoding/gob. Interface types need to be registered with gob.Register.
+	FormatGob Format = 1
+	// FormatJSON encodes entries with encoding/json.
+	FormatJSON Format = 2
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatGob:
+		return "gob"
+	case FormatJSON:
+		return "json"
+	}
+	return fmt.Sprintf("Format(%d)", byte(f))
+}
+
+var (
+	// ErrNotSavedMap is returned by Load if the data wasn't written by Save.
+	ErrNotSavedMap = errors.New("mapz: not a saved map")
+	// ErrUnsupportedVersion is returned by Load if the data was written by an incompatible version of Save.
+	ErrUnsupportedVersion = errors.New("mapz: unsupported saved map version")
+	// ErrCorrupt is returned by Load if the data is truncated or its checksum doesn't match.
+	ErrCorrupt = errors.New("mapz: saved map is corrupt")
+)
+
+// The saved format is a header (persistMagic, persistVersion and the Format), followed by frames of encoded entries.
+// Each frame is a 4 byte big-endian length, the data and a 4 byte CRC-32C of the data.
+// An empty frame terminates the stream and is followed by the number of entries as an 8 byte big-endian integer and its CRC-32C.
+const (
+	persistMagic   = "MAPZ"
+	persistVersion = 1
+
+	maxFrameSize = 64 << 10
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+type persistEntry[K comparable, V any] struct {
+	K K `json:"k"`
+	V V `json:"v"`
+}
+
+// Save writes all entries of m to w in the given format. Use Load to read them back.
+// Entries are encoded as they are visited by m.Range, so Save doesn't need to copy the map, and it is only as consistent as m.Range.
+func Save[K comparable, V any](w io.Writer, format Format, m Loader[K, V]) error {
+	var enc interface{ Encode(v any) error }
+	fw := &frameWriter{w: w}
+	switch format {
+	case FormatGob:
+		enc = gob.NewEncoder(fw)
+	case FormatJSON:
+		enc = json.NewEncoder(fw)
+	default:
+		return fmt.Errorf("mapz: unknown format %v", format)
+	}
+	if _, err := w.Write(append([]byte(persistMagic), persistVersion, byte(format))); err != nil {
+		return err
+	}
+	var n uint64
+	var err error
+	m.Range(func(key K, value V) bool {
+		if err = enc.Encode(persistEntry[K, V]{key, value}); err != nil {
+			return false
+		}
+		n++
+		return true
+	})
+	if err != nil {
+		if fw.err != nil {
+			return fw.err
+		}
+		return fmt.Errorf("mapz: encoding entry: %w", err)
+	}
+	return fw.close(n)
+}
+
+// Load reads a map written by Save from r. The format is detected automatically.
+// Entries are decoded while reading, so only the resulting map is kept in memory. The map is only returned if all data was read and verified successfully.
+// Store the result in a map with StoreAll, or use it directly, like MutexMap{M: m}.
+//
+// Load reads exactly the bytes written by Save and leaves the rest of r unread. Several maps saved to the same stream can be loaded one after another.
+// Load doesn't check that r ends after the map; callers that expect nothing to follow should check that reading r returns io.EOF.
+// Load does many small reads, so wrap r in a bufio.Reader if those are expensive and you don't need the data after the map.
+func Load[K comparable, V any](r io.Reader) (map[K]V, error) {
+	var hdr [len(persistMagic) + 2]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrNotSavedMap
+		}
+		return nil, err
+	}
+	if string(hdr[:len(persistMagic)]) != persistMagic {
+		return nil, ErrNotSavedMap
+	}
+	if v := hdr[len(persistMagic)]; v != persistVersion {
+		return nil, fmt.Errorf("%w: got version %d, want %d", ErrUnsupportedVersion, v, persistVersion)
+	}
+	fr := &frameReader{r: r}
+	var dec interface{ Decode(v any) error }
+	switch format := Format(hdr[len(persistMagic)+1]); format {
+	case FormatGob:
+		dec = gob.NewDecoder(fr)
+	case FormatJSON:
+		dec = json.NewDecoder(fr)
+	default:
+		return nil, fmt.Errorf("%w: unknown format %v", ErrUnsupportedVersion, format)
+	}
+	ret := map[K]V{}
+	var n uint64
+	for {
+		// Gob leaves fields that are zero in the stream untouched, so we need a fresh entry every time.
+		var e persistEntry[K, V]
+		if err := dec.Decode(&e); err != nil {
+			if fr.err != nil {
+				return nil, fr.err
+			}
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("mapz: decoding entry %d: %w", n, err)
+		}
+		ret[e.K] = e.V
+		n++
+	}
+	if fr.count != n {
+		return nil, fmt.Errorf("%w: decoded %d entries, but %d were saved", ErrCorrupt, n, fr.count)
+	}
+	return ret, nil
+}
+
+// frameWriter buffers written data and writes it to w in checksummed frames.
+type frameWriter struct {
+	w   io.Writer
+	buf []byte
+	err error
+}
+
+func (f *frameWriter) Write(p []byte) (int, error) {
+	if f.err != nil {
+		return 0, f.err
+	}
+	f.buf = append(f.buf, p...)
+	for len(f.buf) >= maxFrameSize && f.err == nil {
+		f.writeFrame(f.buf[:maxFrameSize])
+		f.buf = f.buf[:copy(f.buf, f.buf[maxFrameSize:])]
+	}
+	if f.err != nil {
+		return 0, f.err
+	}
+	return len(p), nil
+}
+
+func (f *frameWriter) writeFrame(data []byte) {
+	var hdr [4]byte
+	binary.BigEndian.PutUint32(hdr[:], uint32(len(data)))
+	var crc [4]byte
+	binary.BigEndian.PutUint32(crc[:], crc32.Checksum(data, crcTable))
+	for _, b := range [][]byte{hdr[:], data, crc[:]} {
+		if _, err := f.w.Write(b); err != nil {
+			f.err = err
+			return
+		}
+	}
+}
+
+// close flushes the remaining data and writes the terminating frame with the number of entries.
+func (f *frameWriter) close(entries uint64) error {
+	if len(f.buf) > 0 {
+		f.writeFrame(f.buf)
+	}
+	var trailer [4 + 8 + 4]byte
+	binary.BigEndian.PutUint64(trailer[4:], entries)
+	binary.BigEndian.PutUint32(trailer[12:], crc32.Checksum(trailer[4:12], crcTable))
+	if f.err == nil {
+		_, f.err = f.w.Write(trailer[:])
+	}
+	return f.err
+}
+
+// frameReader reads frames written by frameWriter and returns their data after verifying the checksum.
+type frameReader struct {
+	r     io.Reader
+	data  []byte
+	count uint64
+	done  bool
+	err   error
+}
+
+func (f *frameReader) Read(p []byte) (int, error) {
+	for len(f.data) == 0 {
+		if f.err != nil {
+			return 0, f.err
+		}
+		if f.done {
+			return 0, io.EOF
+		}
+		f.err = f.readFrame()
+	}
+	n := copy(p, f.data)
+	f.data = f.data[n:]
+	return n, nil
+}
+
+func (f *frameReader) readFrame() error {
+	var hdr [4]byte
+	if err := f.readFull(hdr[:]); err != nil {
+		return err
+	}
+	size := binary.BigEndian.Uint32(hdr[:])
+	if size == 0 {
+		var trailer [8 + 4]byte
+		if err := f.readFull(trailer[:]); err != nil {
+			return err
+		}
+		if crc32.Checksum(trailer[:8], crcTable) != binary.BigEndian.Uint32(trailer[8:]) {
+			return fmt.Errorf("%w: checksum mismatch in trailer", ErrCorrupt)
+		}
+		f.count = binary.BigEndian.Uint64(trailer[:8])
+		f.done = true
+		return nil
+	}
+	if size > maxFrameSize {
+		return fmt.Errorf("%w: frame of %d bytes is too large", ErrCorrupt, size)
+	}
+	buf := make([]byte, size+4)
+	if err := f.readFull(buf); err != nil {
+		return err
+	}
+	if crc32.Checksum(buf[:size], crcTable) != binary.BigEndian.Uint32(buf[size:]) {
+		return fmt.Errorf("%w: checksum mismatch", ErrCorrupt)
+	}
+	f.data = buf[:size]
+	return nil
+}
+
+func (f *frameReader) readFull(buf []byte) error {
+	if _, err := io.ReadFull(f.r, buf); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return fmt.Errorf("%w: unexpected end of data", ErrCorrupt)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package mapz
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+type persistValue struct {
+	Name  string
+	Count int
+	Tags  []string
+}
+
+func TestSaveLoad(t *testing.T) {
+	for _, format := range []Format{FormatGob, FormatJSON} {
+		for _, size := range []int{0, 1, 10000} {
+			t.Run(format.String()+"/"+strconv.Itoa(size), func(t *testing.T) {
+				var m MutexMap[string, persistValue]
+				for i := 0; i < size; i++ {
+					// Include zero values, which gob doesn't transmit.
+					m.Store(strconv.Itoa(i), persistValue{Name: "n" + strconv.Itoa(i%3), Count: i % 2, Tags: []string{"x"}[:i%2]})
+				}
+				var buf bytes.Buffer
+				if err := Save[string, persistValue](&buf, format, &m); err != nil {
+					t.Fatalf("Save failed: %v", err)
+				}
+				got, err := Load[string, persistValue](&buf)
+				if err != nil {
+					t.Fatalf("Load failed: %v", err)
+				}
+				want := m.M
+				if want == nil {
+					want = map[string]persistValue{}
+				}
+				if len(got) != len(want) {
+					t.Fatalf("Load returned %d entries; want %d", len(got), len(want))
+				}
+				for k, v := range want {
+					if g := got[k]; g.Name != v.Name || g.Count != v.Count || len(g.Tags) != len(v.Tags) {
+						t.Errorf("Load()[%q] = %+v; want %+v", k, g, v)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestSaveLoadStoreAll(t *testing.T) {
+	var m RWMutexMap[int, string]
+	m.Store(1, "one")
+	m.Store(2, "two")
+	var buf bytes.Buffer
+	if err := Save[int, string](&buf, FormatJSON, &m); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	entries, err := Load[int, string](&buf)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	var restored MutexMap[int, string]
+	restored.StoreAll(entries)
+	if got, want := restored.M, map[int]string{1: "one", 2: "two"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("restored map = %v; want %v", got, want)
+	}
+}
+
+func TestLoadLeavesRestUnread(t *testing.T) {
+	var buf bytes.Buffer
+	for _, format := range []Format{FormatGob, FormatJSON} {
+		if err := Save[int, string](&buf, format, &UnsyncedMap[int, string]{M: map[int]string{int(format): format.String()}}); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+	buf.WriteString("rest")
+	for _, format := range []Format{FormatGob, FormatJSON} {
+		got, err := Load[int, string](&buf)
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+		if want := map[int]string{int(format): format.String()}; !reflect.DeepEqual(got, want) {
+			t.Errorf("Load() = %v; want %v", got, want)
+		}
+	}
+	if got := buf.String(); got != "rest" {
+		t.Errorf("data after the saved maps = %q; want %q", got, "rest")
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	var m UnsyncedMap[int, int]
+	for i := 0; i < 20000; i++ {
+		m.Store(i, i)
+	}
+	var buf bytes.Buffer
+	if err := Save[int, int](&buf, FormatGob, &m); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	saved := buf.Bytes()
+
+	corrupt := func(f func(b []byte) []byte) []byte {
+		return f(append([]byte(nil), saved...))
+	}
+	for _, tc := range []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"empty", nil, ErrNotSavedMap},
+		{"magic", corrupt(func(b []byte) []byte { b[0] = 'X'; return b }), ErrNotSavedMap},
+		{"version", corrupt(func(b []byte) []byte { b[4] = 99; return b }), ErrUnsupportedVersion},
+		{"format", corrupt(func(b []byte) []byte { b[5] = 99; return b }), ErrUnsupportedVersion},
+		{"flipped bit", corrupt(func(b []byte) []byte { b[len(b)/2] ^= 1; return b }), ErrCorrupt},
+		{"truncated", saved[:len(saved)/2], ErrCorrupt},
+		{"missing trailer", saved[:len(saved)-16], ErrCorrupt},
+		{"wrong count", corrupt(func(b []byte) []byte {
+			// Rewrite the trailer with a valid checksum for the wrong count.
+			var other bytes.Buffer
+			Save[int, int](&other, FormatGob, &UnsyncedMap[int, int]{M: map[int]int{1: 1}})
+			return append(b[:len(b)-16], other.Bytes()[other.Len()-16:]...)
+		}), ErrCorrupt},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Load[int, int](bytes.NewReader(tc.data))
+			if !errors.Is(err, tc.want) {
+				t.Errorf("Load() error = %v; want %v", err, tc.want)
+			}
+			if got != nil {
+				t.Errorf("Load() returned a partial map of %d entries", len(got))
+			}
+		})
+	}
+
+	if _, err := Load[string, int](bytes.NewReader(saved)); err == nil {
+		t.Errorf("Load() with the wrong key type succeeded")
+	}
+}
@@ -0,0 +1,211 @@
+package mapz
+
+import (
+	"sync"
+
+	"golang.org/x/exp/maps"
+)
+
+// RWMutexMap is a map protected with a read-write mutex. Its interface closely resembles sync.Map.
+// Unlike MutexMap, Load, LoadOrZero, Range and Len only take a read lock, so readers don't block each other.
+// The zero value is valid.
+type RWMutexMap[K comparable, V any] struct {
+	L sync.RWMutex
+	M map[K]V
+}
+
+// Load returns the value stored in the map for a key. The ok result indicates whether value was found in the map.
+func (m *RWMutexMap[K, V]) Load(key K) (V, bool) {
+	m.L.RLock()
+	defer m.L.RUnlock()
+	v, ok := m.M[key]
+	return v, ok
+}
+
+// LoadOrZero returns the value stored in the map for a key, or zero if no value is present. This is the same as Load() but ignoring the second result.
+func (m *RWMutexMap[K, V]) LoadOrZero(key K) V {
+	m.L.RLock()
+	defer m.L.RUnlock()
+	return m.M[key]
+}
+
+// Store sets the value for a key.
+func (m *RWMutexMap[K, V]) Store(key K, value V) {
+	m.L.Lock()
+	defer m.L.Unlock()
+	if m.M == nil {
+		m.M = map[K]V{}
+	}
+	m.M[key] = value
+}
+
+// Delete deletes the value for a key.
+func (m *RWMutexMap[K, V]) Delete(key K) {
+	m.L.Lock()
+	defer m.L.Unlock()
+	delete(m.M, key)
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if any. The second result reports whether the key was present.
+func (m *RWMutexMap[K, V]) LoadAndDelete(key K) (V, bool) {
+	m.L.Lock()
+	defer m.L.Unlock()
+	v, ok := m.M[key]
+	if ok {
+		delete(m.M, key)
+	}
+	return v, ok
+}
+
+// LoadOrStore returns the existing value for the key if present. Otherwise, it stores and returns the given value. The loaded result is true if the value was loaded, false if stored.
+//
+// LoadOrStore first tries with a read lock, and only takes the write lock if the key wasn't found.
+func (m *RWMutexMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	m.L.RLock()
+	v, ok := m.M[key]
+	m.L.RUnlock()
+	if ok {
+		return v, true
+	}
+	m.L.Lock()
+	defer m.L.Unlock()
+	v, ok = m.M[key]
+	if ok {
+		return v, true
+	}
+	if m.M == nil {
+		m.M = map[K]V{}
+	}
+	m.M[key] = value
+	return value, false
+}
+
+// Swap swaps the value for a key and returns the previous value if any. The loaded result reports whether the key was present.
+func (m *RWMutexMap[K, V]) Swap(key K, value V) (previous V, loaded bool) {
+	m.L.Lock()
+	defer m.L.Unlock()
+	v, ok := m.M[key]
+	if m.M == nil {
+		m.M = map[K]V{}
+	}
+	m.M[key] = value
+	return v, ok
+}
+
+// CompareAndDelete deletes the entry for key if its value is equal to old.
+//
+// If there is no current value for key in the map, CompareAndDelete returns false.
+//
+// This is a function rather than a method because Go 1.18 doesn't allow restricting a method's type parameters more than the base type (yet?).
+func RWMutexMapCompareAndDelete[K, V comparable](m *RWMutexMap[K, V], key K, old V) (deleted bool) {
+	m.L.Lock()
+	defer m.L.Unlock()
+	v, ok := m.M[key]
+	if ok && v == old {
+		delete(m.M, key)
+		return true
+	}
+	return false
+}
+
+// CompareAndSwap swaps the old and new values for key if the value stored in the map is equal to old. The old value must be of a comparable type.
+//
+// This is a function rather than a method because Go 1.18 doesn't allow restricting a method's type parameters more than the base type (yet?).
+func RWMutexMapCompareAndSwap[K, V comparable](m *RWMutexMap[K, V], key K, old, new V) bool {
+	m.L.Lock()
+	defer m.L.Unlock()
+	if m.M[key] == old {
+		if m.M == nil {
+			m.M = map[K]V{}
+		}
+		m.M[key] = new
+		return true
+	}
+	return false
+}
+
+// StoreAll sets the values for all keys in entries. The lock is taken only once.
+func (m *RWMutexMap[K, V]) StoreAll(entries map[K]V) {
+	m.L.Lock()
+	defer m.L.Unlock()
+	if m.M == nil {
+		m.M = make(map[K]V, len(entries))
+	}
+	for k, v := range entries {
+		m.M[k] = v
+	}
+}
+
+// LoadMany returns the values stored in the map for the given keys. Keys that aren't present are omitted from the result. The lock is taken only once.
+func (m *RWMutexMap[K, V]) LoadMany(keys ...K) map[K]V {
+	m.L.RLock()
+	defer m.L.RUnlock()
+	ret := make(map[K]V, len(keys))
+	for _, k := range keys {
+		if v, ok := m.M[k]; ok {
+			ret[k] = v
+		}
+	}
+	return ret
+}
+
+// DeleteMany deletes the values for the given keys. The lock is taken only once.
+func (m *RWMutexMap[K, V]) DeleteMany(keys ...K) {
+	m.L.Lock()
+	defer m.L.Unlock()
+	for _, k := range keys {
+		delete(m.M, k)
+	}
+}
+
+// LoadAndDeleteAll deletes all values from the map and returns them. The lock is taken only once.
+// The returned map is a copy, because a concurrent Range might still be iterating over the old one.
+func (m *RWMutexMap[K, V]) LoadAndDeleteAll() map[K]V {
+	m.L.Lock()
+	defer m.L.Unlock()
+	ret := maps.Clone(m.M)
+	m.M = nil
+	return ret
+}
+
+// WithLock calls f while holding the write lock. f can manipulate the given map at will, but can't use m's regular functions because it's already holding the lock itself.
+func (m *RWMutexMap[K, V]) WithLock(f func(m map[K]V)) {
+	m.L.Lock()
+	defer m.L.Unlock()
+	if m.M == nil {
+		m.M = map[K]V{}
+	}
+	f(m.M)
+}
+
+// WithRLock calls f while holding the read lock. f must not modify the given map, and can't use m's methods that take the write lock.
+// The given map might be nil if nothing was ever stored.
+func (m *RWMutexMap[K, V]) WithRLock(f func(m map[K]V)) {
+	m.L.RLock()
+	defer m.L.RUnlock()
+	f(m.M)
+}
+
+// Range calls f sequentially for each key and value present in the map. If f returns false, range stops the iteration.
+//
+// Range does not block other methods on the receiver; even f itself may call any method on m.
+//
+// Range repeatedly picks up and drops the read lock so f() won't be called with the lock held. Use WithRLock if you need more performance at the cost of blocking writers.
+func (m *RWMutexMap[K, V]) Range(f func(key K, value V) bool) {
+	m.L.RLock()
+	for k, v := range m.M {
+		m.L.RUnlock()
+		if !f(k, v) {
+			return
+		}
+		m.L.RLock()
+	}
+	m.L.RUnlock()
+}
+
+// Len returns the number of elements in the map.
+func (m *RWMutexMap[K, V]) Len() int {
+	m.L.RLock()
+	defer m.L.RUnlock()
+	return len(m.M)
+}
@@ -0,0 +1,122 @@
+package mapz
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestRWMutexMap(t *testing.T) {
+	var m RWMutexMap[string, int]
+	if _, ok := m.Load("a"); ok {
+		t.Errorf("Load on empty map returned ok")
+	}
+	m.WithRLock(func(rm map[string]int) {
+		if len(rm) != 0 {
+			t.Errorf("WithRLock on empty map got %v", rm)
+		}
+	})
+	m.Store("a", 1)
+	if v, ok := m.Load("a"); !ok || v != 1 {
+		t.Errorf("Load(a) = %d, %v; want 1, true", v, ok)
+	}
+	if v, loaded := m.LoadOrStore("a", 2); !loaded || v != 1 {
+		t.Errorf("LoadOrStore(a, 2) = %d, %v; want 1, true", v, loaded)
+	}
+	if v, loaded := m.LoadOrStore("b", 2); loaded || v != 2 {
+		t.Errorf("LoadOrStore(b, 2) = %d, %v; want 2, false", v, loaded)
+	}
+	if v, loaded := m.Swap("b", 3); !loaded || v != 2 {
+		t.Errorf("Swap(b, 3) = %d, %v; want 2, true", v, loaded)
+	}
+	if v, loaded := m.Swap("c", 5); loaded || v != 0 {
+		t.Errorf("Swap(c, 5) = %d, %v; want 0, false", v, loaded)
+	}
+	if RWMutexMapCompareAndSwap(&m, "b", 2, 4) {
+		t.Errorf("CompareAndSwap(b, 2, 4) succeeded")
+	}
+	if !RWMutexMapCompareAndSwap(&m, "b", 3, 4) {
+		t.Errorf("CompareAndSwap(b, 3, 4) failed")
+	}
+	if RWMutexMapCompareAndDelete(&m, "c", 4) {
+		t.Errorf("CompareAndDelete(c, 4) succeeded")
+	}
+	if !RWMutexMapCompareAndDelete(&m, "c", 5) {
+		t.Errorf("CompareAndDelete(c, 5) failed")
+	}
+	if got := m.LoadOrZero("b"); got != 4 {
+		t.Errorf("LoadOrZero(b) = %d; want 4", got)
+	}
+	if got := m.LoadOrZero("c"); got != 0 {
+		t.Errorf("LoadOrZero(c) = %d; want 0", got)
+	}
+	if got := m.Len(); got != 2 {
+		t.Errorf("Len() = %d; want 2", got)
+	}
+	m.WithLock(func(wm map[string]int) {
+		wm["d"] = 6
+	})
+	m.WithRLock(func(rm map[string]int) {
+		if len(rm) != 3 || rm["d"] != 6 {
+			t.Errorf("WithRLock got %v", rm)
+		}
+	})
+	if v, ok := m.LoadAndDelete("b"); !ok || v != 4 {
+		t.Errorf("LoadAndDelete(b) = %d, %v; want 4, true", v, ok)
+	}
+	if _, ok := m.LoadAndDelete("b"); ok {
+		t.Errorf("LoadAndDelete(b) succeeded twice")
+	}
+	m.Delete("a")
+	seen := map[string]int{}
+	m.Range(func(k string, v int) bool {
+		seen[k] = v
+		// Range must not hold the lock while calling f.
+		m.Store("e", 7)
+		return true
+	})
+	if seen["d"] != 6 {
+		t.Errorf("Range didn't yield d: %v", seen)
+	}
+}
+
+func TestRWMutexMapStress(t *testing.T) {
+	var m RWMutexMap[int, int]
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				k := i % 50
+				switch i % 5 {
+				case 0:
+					m.Store(k, i)
+				case 1:
+					m.LoadOrStore(k, i)
+				case 2:
+					m.Swap(k, i)
+				case 3:
+					RWMutexMapCompareAndSwap(&m, k, i-1, i)
+				case 4:
+					m.Delete(k)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				m.Load(i % 50)
+				m.Len()
+				if i%100 == 0 {
+					m.Range(func(k, v int) bool {
+						return true
+					})
+					m.WithRLock(func(rm map[int]int) {
+						_ = rm[i%50]
+					})
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,158 @@
+package mapz
+
+import (
+	"hash/maphash"
+	"runtime"
+	"sync"
+)
+
+// ShardedMap is a map split over multiple shards, each being a MutexMap with its own lock. Its interface closely resembles sync.Map.
+// Operations on keys in different shards don't contend with each other, which makes it scale better than MutexMap on machines with many cores.
+// The zero value is valid and uses the default shard count and hash function. Use NewShardedMap to configure them.
+type ShardedMap[K comparable, V any] struct {
+	once   sync.Once
+	shards []paddedMutexMap[K, V]
+	mask   uint64
+	hash   func(K) uint64
+	seed   maphash.Seed
+}
+
+// paddedMutexMap pads MutexMap to a cache line to avoid false sharing between the locks of adjacent shards.
+type paddedMutexMap[K comparable, V any] struct {
+	MutexMap[K, V]
+	_ [48]byte
+}
+
+// NewShardedMap creates a ShardedMap with the given number of shards, rounded up to a power of two. If shards is <= 0, the default is used, which scales with GOMAXPROCS.
+// hash is used to pick the shard for a key. It may be nil, in which case a default hash function is used that works for any comparable type.
+// Before Go 1.24 the default hash function relies on reflection for types other than strings and integers, so passing your own is faster.
+func NewShardedMap[K comparable, V any](shards int, hash func(K) uint64) *ShardedMap[K, V] {
+	m := &ShardedMap[K, V]{}
+	m.once.Do(func() {
+		m.init(shards, hash)
+	})
+	return m
+}
+
+func (m *ShardedMap[K, V]) init(shards int, hash func(K) uint64) {
+	if shards <= 0 {
+		shards = 4 * runtime.GOMAXPROCS(0)
+	}
+	n := 1
+	for n < shards {
+		n <<= 1
+	}
+	m.shards = make([]paddedMutexMap[K, V], n)
+	m.mask = uint64(n - 1)
+	m.hash = hash
+	if m.hash == nil {
+		m.seed = maphash.MakeSeed()
+		m.hash = func(key K) uint64 {
+			return defaultHash(m.seed, key)
+		}
+	}
+}
+
+func (m *ShardedMap[K, V]) lazyInit() {
+	m.once.Do(func() {
+		m.init(0, nil)
+	})
+}
+
+func (m *ShardedMap[K, V]) shard(key K) *MutexMap[K, V] {
+	m.lazyInit()
+	return &m.shards[m.hash(key)&m.mask].MutexMap
+}
+
+// Load returns the value stored in the map for a key. The ok result indicates whether value was found in the map.
+func (m *ShardedMap[K, V]) Load(key K) (V, bool) {
+	return m.shard(key).Load(key)
+}
+
+// LoadOrZero returns the value stored in the map for a key, or zero if no value is present. This is the same as Load() but ignoring the second result.
+func (m *ShardedMap[K, V]) LoadOrZero(key K) V {
+	return m.shard(key).LoadOrZero(key)
+}
+
+// Store sets the value for a key.
+func (m *ShardedMap[K, V]) Store(key K, value V) {
+	m.shard(key).Store(key, value)
+}
+
+// Delete deletes the value for a key.
+func (m *ShardedMap[K, V]) Delete(key K) {
+	m.shard(key).Delete(key)
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if any. The second result reports whether the key was present.
+func (m *ShardedMap[K, V]) LoadAndDelete(key K) (V, bool) {
+	return m.shard(key).LoadAndDelete(key)
+}
+
+// LoadOrStore returns the existing value for the key if present. Otherwise, it stores and returns the given value. The loaded result is true if the value was loaded, false if stored.
+func (m *ShardedMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	return m.shard(key).LoadOrStore(key, value)
+}
+
+// Swap swaps the value for a key and returns the previous value if any. The loaded result reports whether the key was present.
+func (m *ShardedMap[K, V]) Swap(key K, value V) (previous V, loaded bool) {
+	return m.shard(key).Swap(key, value)
+}
+
+// CompareAndDelete deletes the entry for key if its value is equal to old.
+//
+// If there is no current value for key in the map, CompareAndDelete returns false.
+//
+// This is a function rather than a method because Go 1.18 doesn't allow restricting a method's type parameters more than the base type (yet?).
+func ShardedMapCompareAndDelete[K, V comparable](m *ShardedMap[K, V], key K, old V) (deleted bool) {
+	return MutexMapCompareAndDelete(m.shard(key), key, old)
+}
+
+// CompareAndSwap swaps the old and new values for key if the value stored in the map is equal to old. The old value must be of a comparable type.
+//
+// This is a function rather than a method because Go 1.18 doesn't allow restricting a method's type parameters more than the base type (yet?).
+func ShardedMapCompareAndSwap[K, V comparable](m *ShardedMap[K, V], key K, old, new V) bool {
+	return MutexMapCompareAndSwap(m.shard(key), key, old, new)
+}
+
+// WithLock calls f once for every shard while holding that shard's lock. f can read, overwrite and delete entries in the given map at will, but must not add new keys because they might belong in another shard.
+// f can't use m's regular functions for keys in the same shard because it's already holding the lock itself.
+func (m *ShardedMap[K, V]) WithLock(f func(m map[K]V)) {
+	m.lazyInit()
+	for i := range m.shards {
+		m.shards[i].WithLock(f)
+	}
+}
+
+// Range calls f sequentially for each key and value present in the map. If f returns false, range stops the iteration.
+//
+// Range does not block other methods on the receiver; even f itself may call any method on m.
+//
+// Range visits the shards one by one and has the same guarantees as MutexMap.Range within each shard. It doesn't correspond to a consistent snapshot of the whole map.
+func (m *ShardedMap[K, V]) Range(f func(key K, value V) bool) {
+	m.lazyInit()
+	stopped := false
+	for i := range m.shards {
+		m.shards[i].Range(func(key K, value V) bool {
+			if !f(key, value) {
+				stopped = true
+				return false
+			}
+			return true
+		})
+		if stopped {
+			return
+		}
+	}
+}
+
+// Len returns the number of elements in the map.
+// Shards are counted one by one, so the result is not necessarily exact if other goroutines modify the map concurrently.
+func (m *ShardedMap[K, V]) Len() int {
+	m.lazyInit()
+	n := 0
+	for i := range m.shards {
+		n += m.shards[i].Len()
+	}
+	return n
+}
@@ -0,0 +1,10 @@
+//go:build go1.24
+
+package mapz
+
+import "hash/maphash"
+
+// defaultHash hashes any comparable key. From Go 1.24 this is done by maphash.Comparable.
+func defaultHash[K comparable](seed maphash.Seed, key K) uint64 {
+	return maphash.Comparable(seed, key)
+}
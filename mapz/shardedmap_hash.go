@@ -0,0 +1,97 @@
+//go:build !go1.24
+
+package mapz
+
+import (
+	"encoding/binary"
+	"hash/maphash"
+	"math"
+	"reflect"
+)
+
+// defaultHash hashes any comparable key. Strings and integers are hashed directly, other types are hashed with reflection.
+// Go 1.24 added maphash.Comparable, which is used instead when available.
+func defaultHash[K comparable](seed maphash.Seed, key K) uint64 {
+	var h maphash.Hash
+	h.SetSeed(seed)
+	switch k := any(key).(type) {
+	case string:
+		h.WriteString(k)
+	case int:
+		writeUint64(&h, uint64(k))
+	case int64:
+		writeUint64(&h, uint64(k))
+	case int32:
+		writeUint64(&h, uint64(k))
+	case uint:
+		writeUint64(&h, uint64(k))
+	case uint64:
+		writeUint64(&h, k)
+	case uint32:
+		writeUint64(&h, uint64(k))
+	default:
+		hashValue(&h, reflect.ValueOf(&key).Elem())
+	}
+	return h.Sum64()
+}
+
+func writeUint64(h *maphash.Hash, v uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	h.Write(b[:])
+}
+
+// hashValue writes v to h such that values that are == to each other result in the same bytes being written.
+func hashValue(h *maphash.Hash, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			h.WriteByte(1)
+		} else {
+			h.WriteByte(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeUint64(h, uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		writeUint64(h, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		writeFloat(h, v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		writeFloat(h, real(c))
+		writeFloat(h, imag(c))
+	case reflect.String:
+		// Prefix the length so that e.g. [2]string{"ab", ""} and [2]string{"a", "b"} don't collide.
+		writeUint64(h, uint64(v.Len()))
+		h.WriteString(v.String())
+	case reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		writeUint64(h, uint64(v.Pointer()))
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			hashValue(h, v.Index(i))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			hashValue(h, v.Field(i))
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			h.WriteByte(0)
+			return
+		}
+		// Interfaces holding different types are never equal, so hash the type too to avoid e.g. int(1) and int8(1) colliding.
+		h.WriteByte(1)
+		h.WriteString(v.Elem().Type().String())
+		hashValue(h, v.Elem())
+	default:
+		panic("mapz: can't hash key of kind " + v.Kind().String())
+	}
+}
+
+func writeFloat(h *maphash.Hash, f float64) {
+	if f == 0 {
+		// +0 and -0 are equal, so they must hash the same.
+		f = 0
+	}
+	writeUint64(h, math.Float64bits(f))
+}
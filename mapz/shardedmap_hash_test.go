@@ -0,0 +1,84 @@
+//go:build !go1.24
+
+package mapz
+
+import (
+	"hash/maphash"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type hashTestKey struct {
+	s string
+	f float64
+	c complex128
+	b bool
+	p *int
+	a [2]int8
+}
+
+func TestDefaultHashReflection(t *testing.T) {
+	seed := maphash.MakeSeed()
+	x, y := 1, 1
+	negZero := math.Copysign(0, -1)
+
+	structs := []hashTestKey{
+		{},
+		{s: "a"},
+		{s: "a", f: 1.5},
+		{s: "a", f: 1.5, c: complex(1, 2)},
+		{b: true},
+		{p: &x},
+		{p: &y},
+		{a: [2]int8{1, 2}},
+		{a: [2]int8{2, 1}},
+		// Equal to earlier keys, but built differently.
+		{s: strings.Repeat("a", 1), f: 3.0 / 2},
+		{p: &x},
+		{a: [2]int8{1, 1 + 1}},
+		// +0 and -0 are equal, so they must hash the same.
+		{f: negZero, c: complex(negZero, negZero)},
+	}
+	checkDefaultHash(t, seed, structs)
+	checkDefaultHash(t, seed, [][3]string{{}, {"a"}, {"a", "b"}, {"", "a", "b"}, {"ab"}, {strings.Repeat("a", 1), "b"}})
+
+	// Interfaces only satisfy comparable since Go 1.20, and go.mod says 1.18, so this test can't instantiate defaultHash with them.
+	// Hash them the way defaultHash's reflection fallback does instead.
+	ifaces := []any{
+		nil, 1, int8(1), "1", 1.5, [2]bool{true, false}, &x, struct{}{}, hashTestKey{s: "a"}, 0.0, [1]any{1}, [1]any{"1"}, [1]any{nil},
+		// Equal to earlier keys, but built differently.
+		negZero, 2 - 1, strings.Repeat("1", 1), hashTestKey{s: strings.ToLower("A")}, [1]any{2 - 1},
+	}
+	hashes := make([]uint64, len(ifaces))
+	for i, k := range ifaces {
+		var h maphash.Hash
+		h.SetSeed(seed)
+		hashValue(&h, reflect.ValueOf(&k).Elem())
+		hashes[i] = h.Sum64()
+	}
+	checkHashes(t, ifaces, hashes, func(a, b any) bool { return a == b })
+}
+
+// checkDefaultHash checks that keys that are equal have the same hash, and that other keys don't.
+// Keys that aren't equal could collide in theory, but not with these few test keys.
+func checkDefaultHash[K comparable](t *testing.T, seed maphash.Seed, keys []K) {
+	t.Helper()
+	hashes := make([]uint64, len(keys))
+	for i, k := range keys {
+		hashes[i] = defaultHash(seed, k)
+	}
+	checkHashes(t, keys, hashes, func(a, b K) bool { return a == b })
+}
+
+func checkHashes[K any](t *testing.T, keys []K, hashes []uint64, equal func(a, b K) bool) {
+	t.Helper()
+	for i := range keys {
+		for j := 0; j < i; j++ {
+			if eq := equal(keys[i], keys[j]); eq != (hashes[i] == hashes[j]) {
+				t.Errorf("%#v == %#v is %v, but their hashes are %x and %x", keys[i], keys[j], eq, hashes[i], hashes[j])
+			}
+		}
+	}
+}
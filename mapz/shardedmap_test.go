@@ -0,0 +1,125 @@
+package mapz
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestShardedMap(t *testing.T) {
+	var m ShardedMap[string, int]
+	if _, ok := m.Load("a"); ok {
+		t.Errorf("Load on empty map returned ok")
+	}
+	m.Store("a", 1)
+	if v, ok := m.Load("a"); !ok || v != 1 {
+		t.Errorf("Load(a) = %d, %v; want 1, true", v, ok)
+	}
+	if v, loaded := m.LoadOrStore("a", 2); !loaded || v != 1 {
+		t.Errorf("LoadOrStore(a, 2) = %d, %v; want 1, true", v, loaded)
+	}
+	if v, loaded := m.LoadOrStore("b", 2); loaded || v != 2 {
+		t.Errorf("LoadOrStore(b, 2) = %d, %v; want 2, false", v, loaded)
+	}
+	if v, loaded := m.Swap("b", 3); !loaded || v != 2 {
+		t.Errorf("Swap(b, 3) = %d, %v; want 2, true", v, loaded)
+	}
+	if !ShardedMapCompareAndSwap(&m, "b", 3, 4) {
+		t.Errorf("CompareAndSwap(b, 3, 4) failed")
+	}
+	if ShardedMapCompareAndDelete(&m, "b", 3) {
+		t.Errorf("CompareAndDelete(b, 3) succeeded")
+	}
+	if got := m.LoadOrZero("b"); got != 4 {
+		t.Errorf("LoadOrZero(b) = %d; want 4", got)
+	}
+	if got := m.Len(); got != 2 {
+		t.Errorf("Len() = %d; want 2", got)
+	}
+	if v, ok := m.LoadAndDelete("b"); !ok || v != 4 {
+		t.Errorf("LoadAndDelete(b) = %d, %v; want 4, true", v, ok)
+	}
+	m.Delete("a")
+	if got := m.Len(); got != 0 {
+		t.Errorf("Len() = %d; want 0", got)
+	}
+}
+
+func TestShardedMapRangeAndWithLock(t *testing.T) {
+	m := NewShardedMap[int, int](3, nil)
+	if got := len(m.shards); got != 4 {
+		t.Errorf("NewShardedMap(3) created %d shards; want 4", got)
+	}
+	for i := 0; i < 100; i++ {
+		m.Store(i, i)
+	}
+	m.WithLock(func(sm map[int]int) {
+		for k := range sm {
+			sm[k] *= 2
+		}
+	})
+	seen := map[int]int{}
+	m.Range(func(k, v int) bool {
+		seen[k] = v
+		return true
+	})
+	if len(seen) != 100 {
+		t.Errorf("Range visited %d keys; want 100", len(seen))
+	}
+	for k, v := range seen {
+		if v != 2*k {
+			t.Errorf("Range yielded %d => %d; want %d", k, v, 2*k)
+		}
+	}
+	calls := 0
+	m.Range(func(k, v int) bool {
+		calls++
+		return false
+	})
+	if calls != 1 {
+		t.Errorf("Range continued after f returned false: %d calls", calls)
+	}
+}
+
+func TestShardedMapConcurrent(t *testing.T) {
+	m := NewShardedMap[string, int](0, nil)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				k := fmt.Sprint(g, "-", i)
+				m.Store(k, i)
+				if v, ok := m.Load(k); !ok || v != i {
+					t.Errorf("Load(%q) = %d, %v; want %d, true", k, v, ok, i)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	if got := m.Len(); got != 8000 {
+		t.Errorf("Len() = %d; want 8000", got)
+	}
+}
+
+type shardedMapTestKey struct {
+	s string
+	f float64
+	p *int
+}
+
+func TestShardedMapDefaultHash(t *testing.T) {
+	var m ShardedMap[shardedMapTestKey, int]
+	x := 5
+	m.Store(shardedMapTestKey{"a", 0, &x}, 1)
+	m.Store(shardedMapTestKey{"b", 1.5, nil}, 2)
+	negZero := 0.0
+	negZero = -negZero
+	if v, ok := m.Load(shardedMapTestKey{"a", negZero, &x}); !ok || v != 1 {
+		t.Errorf("Load with -0 = %d, %v; want 1, true", v, ok)
+	}
+	if v, ok := m.Load(shardedMapTestKey{"b", 1.5, nil}); !ok || v != 2 {
+		t.Errorf("Load(b) = %d, %v; want 2, true", v, ok)
+	}
+}
@@ -0,0 +1,226 @@
+package mapz
+
+import "golang.org/x/exp/constraints"
+
+// SortedMap is a map that keeps its keys in sorted order. It is implemented as a B-tree.
+// Load, Store, Delete, Min, Max, Floor, Ceiling, Rank and Select are O(log n). Iterating over the map in either direction is O(n), from any starting point.
+// Use SortedMapFunc for keys that don't have a natural order.
+//
+// It isn't safe for concurrent use. Use ConcurrentSortedMap if you need that.
+// The zero value is valid. Floating point NaNs are sorted before all other values.
+type SortedMap[K constraints.Ordered, V any] struct {
+	t btree[K, V]
+}
+
+func (m *SortedMap[K, V]) tree() *btree[K, V] {
+	if m.t.cmp == nil {
+		m.t.cmp = compareOrdered[K]
+	}
+	return &m.t
+}
+
+var _ Map[string, int] = &SortedMap[string, int]{}
+
+// Load returns the value stored in the map for a key. The ok result indicates whether value was found in the map.
+func (m *SortedMap[K, V]) Load(key K) (V, bool) {
+	return m.tree().load(key)
+}
+
+// LoadOrZero returns the value stored in the map for a key, or zero if no value is present. This is the same as Load() but ignoring the second result.
+func (m *SortedMap[K, V]) LoadOrZero(key K) V {
+	v, _ := m.tree().load(key)
+	return v
+}
+
+// Store sets the value for a key.
+func (m *SortedMap[K, V]) Store(key K, value V) {
+	m.tree().swap(key, value)
+}
+
+// Swap swaps the value for a key and returns the previous value if any. The loaded result reports whether the key was present.
+func (m *SortedMap[K, V]) Swap(key K, value V) (previous V, loaded bool) {
+	return m.tree().swap(key, value)
+}
+
+// LoadOrStore returns the existing value for the key if present. Otherwise, it stores and returns the given value. The loaded result is true if the value was loaded, false if stored.
+func (m *SortedMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	return m.tree().loadOrStore(key, value)
+}
+
+// Delete deletes the value for a key.
+func (m *SortedMap[K, V]) Delete(key K) {
+	m.tree().delete(key)
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if any. The second result reports whether the key was present.
+func (m *SortedMap[K, V]) LoadAndDelete(key K) (V, bool) {
+	return m.tree().delete(key)
+}
+
+// Len returns the number of elements in the map.
+func (m *SortedMap[K, V]) Len() int {
+	return m.tree().len()
+}
+
+// Min returns the smallest key and its value. The ok result is false if the map is empty.
+func (m *SortedMap[K, V]) Min() (key K, value V, ok bool) {
+	return m.tree().first()
+}
+
+// Max returns the largest key and its value. The ok result is false if the map is empty.
+func (m *SortedMap[K, V]) Max() (key K, value V, ok bool) {
+	return m.tree().last()
+}
+
+// Floor returns the greatest key less than or equal to the given key, and its value. The ok result is false if there is no such key.
+func (m *SortedMap[K, V]) Floor(key K) (K, V, bool) {
+	return m.tree().floor(key)
+}
+
+// Ceiling returns the least key greater than or equal to the given key, and its value. The ok result is false if there is no such key.
+func (m *SortedMap[K, V]) Ceiling(key K) (K, V, bool) {
+	return m.tree().ceiling(key)
+}
+
+// Rank returns the number of keys less than the given key. If key is present, that is its index in sorted order.
+func (m *SortedMap[K, V]) Rank(key K) int {
+	return m.tree().rank(key)
+}
+
+// Select returns the key with the given index in sorted order (starting at 0), and its value. The ok result is false if the index is out of range.
+func (m *SortedMap[K, V]) Select(index int) (key K, value V, ok bool) {
+	return m.tree().selectAt(index)
+}
+
+// Range calls f sequentially for each key and value present in the map, in ascending order. If f returns false, range stops the iteration.
+//
+// f may modify the map. Range continues with the next key greater than the last visited one, so keys added after that are visited and deleted keys are not.
+func (m *SortedMap[K, V]) Range(f func(key K, value V) bool) {
+	m.tree().ascend(nil, nil, f)
+}
+
+// RangeBackward is like Range, but iterates in descending order.
+func (m *SortedMap[K, V]) RangeBackward(f func(key K, value V) bool) {
+	m.tree().descend(nil, nil, f)
+}
+
+// RangeBetween is like Range, but only visits keys greater than or equal to from and less than to.
+func (m *SortedMap[K, V]) RangeBetween(from, to K, f func(key K, value V) bool) {
+	m.tree().ascend(&from, &to, f)
+}
+
+// RangeBetweenBackward is like RangeBetween, but iterates in descending order. It visits keys less than to and greater than or equal to from.
+func (m *SortedMap[K, V]) RangeBetweenBackward(from, to K, f func(key K, value V) bool) {
+	m.tree().descend(&from, &to, f)
+}
+
+// SortedMapFunc is a SortedMap that orders its keys with a comparison function. Keys for which the function returns 0 are considered equal, so K doesn't need to be comparable.
+// SortedMapFunc must be created with NewSortedMapFunc.
+type SortedMapFunc[K, V any] struct {
+	t btree[K, V]
+}
+
+// NewSortedMapFunc creates an empty SortedMapFunc that orders its keys with cmp. cmp should return a negative number if a < b, a positive number if a > b and 0 if they are equal, like cmp.Compare.
+func NewSortedMapFunc[K, V any](cmp func(a, b K) int) *SortedMapFunc[K, V] {
+	return &SortedMapFunc[K, V]{t: btree[K, V]{cmp: cmp}}
+}
+
+func (m *SortedMapFunc[K, V]) tree() *btree[K, V] {
+	if m.t.cmp == nil {
+		panic("mapz: SortedMapFunc must be created with NewSortedMapFunc")
+	}
+	return &m.t
+}
+
+// Load returns the value stored in the map for a key. The ok result indicates whether value was found in the map.
+func (m *SortedMapFunc[K, V]) Load(key K) (V, bool) {
+	return m.tree().load(key)
+}
+
+// LoadOrZero returns the value stored in the map for a key, or zero if no value is present. This is the same as Load() but ignoring the second result.
+func (m *SortedMapFunc[K, V]) LoadOrZero(key K) V {
+	v, _ := m.tree().load(key)
+	return v
+}
+
+// Store sets the value for a key.
+func (m *SortedMapFunc[K, V]) Store(key K, value V) {
+	m.tree().swap(key, value)
+}
+
+// Swap swaps the value for a key and returns the previous value if any. The loaded result reports whether the key was present.
+func (m *SortedMapFunc[K, V]) Swap(key K, value V) (previous V, loaded bool) {
+	return m.tree().swap(key, value)
+}
+
+// LoadOrStore returns the existing value for the key if present. Otherwise, it stores and returns the given value. The loaded result is true if the value was loaded, false if stored.
+func (m *SortedMapFunc[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	return m.tree().loadOrStore(key, value)
+}
+
+// Delete deletes the value for a key.
+func (m *SortedMapFunc[K, V]) Delete(key K) {
+	m.tree().delete(key)
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if any. The second result reports whether the key was present.
+func (m *SortedMapFunc[K, V]) LoadAndDelete(key K) (V, bool) {
+	return m.tree().delete(key)
+}
+
+// Len returns the number of elements in the map.
+func (m *SortedMapFunc[K, V]) Len() int {
+	return m.tree().len()
+}
+
+// Min returns the smallest key and its value. The ok result is false if the map is empty.
+func (m *SortedMapFunc[K, V]) Min() (key K, value V, ok bool) {
+	return m.tree().first()
+}
+
+// Max returns the largest key and its value. The ok result is false if the map is empty.
+func (m *SortedMapFunc[K, V]) Max() (key K, value V, ok bool) {
+	return m.tree().last()
+}
+
+// Floor returns the greatest key less than or equal to the given key, and its value. The ok result is false if there is no such key.
+func (m *SortedMapFunc[K, V]) Floor(key K) (K, V, bool) {
+	return m.tree().floor(key)
+}
+
+// Ceiling returns the least key greater than or equal to the given key, and its value. The ok result is false if there is no such key.
+func (m *SortedMapFunc[K, V]) Ceiling(key K) (K, V, bool) {
+	return m.tree().ceiling(key)
+}
+
+// Rank returns the number of keys less than the given key. If key is present, that is its index in sorted order.
+func (m *SortedMapFunc[K, V]) Rank(key K) int {
+	return m.tree().rank(key)
+}
+
+// Select returns the key with the given index in sorted order (starting at 0), and its value. The ok result is false if the index is out of range.
+func (m *SortedMapFunc[K, V]) Select(index int) (key K, value V, ok bool) {
+	return m.tree().selectAt(index)
+}
+
+// Range calls f sequentially for each key and value present in the map, in ascending order. If f returns false, range stops the iteration.
+//
+// f may modify the map. Range continues with the next key greater than the last visited one, so keys added after that are visited and deleted keys are not.
+func (m *SortedMapFunc[K, V]) Range(f func(key K, value V) bool) {
+	m.tree().ascend(nil, nil, f)
+}
+
+// RangeBackward is like Range, but iterates in descending order.
+func (m *SortedMapFunc[K, V]) RangeBackward(f func(key K, value V) bool) {
+	m.tree().descend(nil, nil, f)
+}
+
+// RangeBetween is like Range, but only visits keys greater than or equal to from and less than to.
+func (m *SortedMapFunc[K, V]) RangeBetween(from, to K, f func(key K, value V) bool) {
+	m.tree().ascend(&from, &to, f)
+}
+
+// RangeBetweenBackward is like RangeBetween, but iterates in descending order. It visits keys less than to and greater than or equal to from.
+func (m *SortedMapFunc[K, V]) RangeBetweenBackward(from, to K, f func(key K, value V) bool) {
+	m.tree().descend(&from, &to, f)
+}
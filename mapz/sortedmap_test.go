@@ -0,0 +1,266 @@
+package mapz
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// checkBTree verifies the invariants of the B-tree and returns its keys in order.
+func checkBTree[K, V any](t *testing.T, tr *btree[K, V]) []K {
+	t.Helper()
+	var keys []K
+	var walk func(n *btreeNode[K, V], depth int, root bool) (size, leafDepth int)
+	walk = func(n *btreeNode[K, V], depth int, root bool) (int, int) {
+		if len(n.keys) != len(n.values) {
+			t.Fatalf("node has %d keys and %d values", len(n.keys), len(n.values))
+		}
+		if len(n.keys) > 2*btreeDegree-1 || (!root && len(n.keys) < btreeDegree-1) {
+			t.Fatalf("node has %d keys", len(n.keys))
+		}
+		if n.leaf() {
+			keys = append(keys, n.keys...)
+			if n.size != len(n.keys) {
+				t.Fatalf("leaf has size %d, but %d keys", n.size, len(n.keys))
+			}
+			return n.size, depth
+		}
+		if len(n.children) != len(n.keys)+1 {
+			t.Fatalf("node has %d keys and %d children", len(n.keys), len(n.children))
+		}
+		size := len(n.keys)
+		leafDepth := -1
+		for i, c := range n.children {
+			s, d := walk(c, depth+1, false)
+			if leafDepth != -1 && d != leafDepth {
+				t.Fatalf("leaves at depths %d and %d", leafDepth, d)
+			}
+			leafDepth = d
+			size += s
+			if i < len(n.keys) {
+				keys = append(keys, n.keys[i])
+			}
+		}
+		if n.size != size {
+			t.Fatalf("node has size %d; want %d", n.size, size)
+		}
+		return size, leafDepth
+	}
+	if tr.root != nil {
+		walk(tr.root, 0, true)
+	}
+	for i := 1; i < len(keys); i++ {
+		if tr.cmp(keys[i-1], keys[i]) >= 0 {
+			t.Fatalf("keys out of order: %v before %v", keys[i-1], keys[i])
+		}
+	}
+	return keys
+}
+
+func TestSortedMapRandomized(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	var m SortedMap[int, int]
+	want := map[int]int{}
+	for round := 0; round < 20000; round++ {
+		k := rnd.Intn(2000)
+		switch op := rnd.Intn(10); {
+		case op < 5:
+			p, loaded := m.Swap(k, round)
+			wp, wloaded := want[k]
+			if p != wp || loaded != wloaded {
+				t.Fatalf("Swap(%d) = %d, %v; want %d, %v", k, p, loaded, wp, wloaded)
+			}
+			want[k] = round
+		case op < 8:
+			v, ok := m.LoadAndDelete(k)
+			wv, wok := want[k]
+			if v != wv || ok != wok {
+				t.Fatalf("LoadAndDelete(%d) = %d, %v; want %d, %v", k, v, ok, wv, wok)
+			}
+			delete(want, k)
+		default:
+			v, loaded := m.LoadOrStore(k, round)
+			if wv, ok := want[k]; ok {
+				if !loaded || v != wv {
+					t.Fatalf("LoadOrStore(%d) = %d, %v; want %d, true", k, v, loaded, wv)
+				}
+			} else {
+				want[k] = round
+			}
+		}
+		if round%1000 != 0 {
+			continue
+		}
+		keys := checkBTree(t, &m.t)
+		if len(keys) != len(want) || m.Len() != len(want) {
+			t.Fatalf("map has %d keys and Len() %d; want %d", len(keys), m.Len(), len(want))
+		}
+		for i, k := range keys {
+			if v, ok := m.Load(k); !ok || v != want[k] {
+				t.Fatalf("Load(%d) = %d, %v; want %d, true", k, v, ok, want[k])
+			}
+			if r := m.Rank(k); r != i {
+				t.Fatalf("Rank(%d) = %d; want %d", k, r, i)
+			}
+			if sk, _, ok := m.Select(i); !ok || sk != k {
+				t.Fatalf("Select(%d) = %d, %v; want %d", i, sk, ok, k)
+			}
+		}
+		for q := -1; q <= 2000; q += 7 {
+			i := sort.SearchInts(keys, q)
+			if fk, _, ok := m.Ceiling(q); ok != (i < len(keys)) || (ok && fk != keys[i]) {
+				t.Fatalf("Ceiling(%d) = %d, %v", q, fk, ok)
+			}
+			j := sort.SearchInts(keys, q+1) - 1
+			if fk, _, ok := m.Floor(q); ok != (j >= 0) || (ok && fk != keys[j]) {
+				t.Fatalf("Floor(%d) = %d, %v", q, fk, ok)
+			}
+			if r := m.Rank(q); r != i {
+				t.Fatalf("Rank(%d) = %d; want %d", q, r, i)
+			}
+		}
+	}
+	for k := range want {
+		m.Delete(k)
+	}
+	if m.Len() != 0 || m.t.root != nil {
+		t.Errorf("map isn't empty after deleting everything")
+	}
+}
+
+func sortedMapKeys(m *SortedMap[int, string], rng func(f func(int, string) bool)) []int {
+	var ret []int
+	rng(func(k int, v string) bool {
+		ret = append(ret, k)
+		return true
+	})
+	return ret
+}
+
+func TestSortedMapRange(t *testing.T) {
+	var m SortedMap[int, string]
+	if _, _, ok := m.Min(); ok {
+		t.Errorf("Min() on an empty map succeeded")
+	}
+	if _, _, ok := m.Select(0); ok {
+		t.Errorf("Select(0) on an empty map succeeded")
+	}
+	for i := 0; i < 1000; i += 2 {
+		m.Store(i, "x")
+	}
+	if k, _, _ := m.Min(); k != 0 {
+		t.Errorf("Min() = %d; want 0", k)
+	}
+	if k, _, _ := m.Max(); k != 998 {
+		t.Errorf("Max() = %d; want 998", k)
+	}
+	if got := sortedMapKeys(&m, m.Range); len(got) != 500 || !sort.IntsAreSorted(got) {
+		t.Errorf("Range visited %d keys, sorted: %v", len(got), sort.IntsAreSorted(got))
+	}
+	if got := sortedMapKeys(&m, m.RangeBackward); len(got) != 500 || got[0] != 998 || got[499] != 0 {
+		t.Errorf("RangeBackward visited %d keys from %d to %d", len(got), got[0], got[len(got)-1])
+	}
+	between := func(from, to int) func(f func(int, string) bool) {
+		return func(f func(int, string) bool) { m.RangeBetween(from, to, f) }
+	}
+	betweenBackward := func(from, to int) func(f func(int, string) bool) {
+		return func(f func(int, string) bool) { m.RangeBetweenBackward(from, to, f) }
+	}
+	if got, want := sortedMapKeys(&m, between(11, 20)), []int{12, 14, 16, 18}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RangeBetween(11, 20) = %v; want %v", got, want)
+	}
+	if got, want := sortedMapKeys(&m, between(12, 19)), []int{12, 14, 16, 18}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RangeBetween(12, 19) = %v; want %v", got, want)
+	}
+	if got, want := sortedMapKeys(&m, betweenBackward(11, 20)), []int{18, 16, 14, 12}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RangeBetweenBackward(11, 20) = %v; want %v", got, want)
+	}
+	if got, want := sortedMapKeys(&m, betweenBackward(12, 18)), []int{16, 14, 12}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RangeBetweenBackward(12, 18) = %v; want %v", got, want)
+	}
+	if got := sortedMapKeys(&m, between(20, 10)); len(got) != 0 {
+		t.Errorf("RangeBetween(20, 10) = %v; want nothing", got)
+	}
+
+	// Modify the map while iterating: delete the next key and insert keys both behind and ahead of the iterator.
+	var visited []int
+	m.RangeBetween(100, 120, func(k int, v string) bool {
+		visited = append(visited, k)
+		m.Delete(k + 2)
+		m.Store(k-1, "behind")
+		m.Store(k+3, "ahead")
+		return true
+	})
+	if want := []int{100, 103, 104, 107, 108, 111, 112, 115, 116, 119}; !reflect.DeepEqual(visited, want) {
+		t.Errorf("RangeBetween with modifications visited %v; want %v", visited, want)
+	}
+	checkBTree(t, &m.t)
+	visited = nil
+	m.RangeBetweenBackward(500, 520, func(k int, v string) bool {
+		visited = append(visited, k)
+		m.Delete(k - 2)
+		m.Store(k+1, "behind")
+		m.Store(k-3, "ahead")
+		return true
+	})
+	if want := []int{518, 515, 514, 511, 510, 507, 506, 503, 502}; !reflect.DeepEqual(visited, want) {
+		t.Errorf("RangeBetweenBackward with modifications visited %v; want %v", visited, want)
+	}
+	checkBTree(t, &m.t)
+}
+
+func TestSortedMapNaN(t *testing.T) {
+	var m SortedMap[float64, int]
+	m.Store(1, 1)
+	m.Store(math.NaN(), 2)
+	m.Store(math.Inf(-1), 3)
+	m.Store(math.NaN(), 4)
+	if m.Len() != 3 {
+		t.Errorf("Len() = %d; want 3", m.Len())
+	}
+	if k, v, _ := m.Min(); !math.IsNaN(k) || v != 4 {
+		t.Errorf("Min() = %v, %d; want NaN, 4", k, v)
+	}
+	if v, ok := m.Load(math.NaN()); !ok || v != 4 {
+		t.Errorf("Load(NaN) = %d, %v; want 4, true", v, ok)
+	}
+}
+
+func TestSortedMapFunc(t *testing.T) {
+	m := NewSortedMapFunc[[]byte, int](bytes.Compare)
+	for i, k := range []string{"banana", "apple", "cherry"} {
+		m.Store([]byte(k), i)
+	}
+	if v, ok := m.Load([]byte("apple")); !ok || v != 1 {
+		t.Errorf("Load(apple) = %d, %v; want 1, true", v, ok)
+	}
+	if k, _, ok := m.Ceiling([]byte("b")); !ok || string(k) != "banana" {
+		t.Errorf("Ceiling(b) = %q, %v; want banana", k, ok)
+	}
+	if k, _, ok := m.Floor([]byte("b")); !ok || string(k) != "apple" {
+		t.Errorf("Floor(b) = %q, %v; want apple", k, ok)
+	}
+	if r := m.Rank([]byte("cherry")); r != 2 {
+		t.Errorf("Rank(cherry) = %d; want 2", r)
+	}
+
+	// Reverse order.
+	r := NewSortedMapFunc[int, string](func(a, b int) int { return b - a })
+	for i := 0; i < 5; i++ {
+		r.Store(i, "")
+	}
+	if k, _, _ := r.Min(); k != 4 {
+		t.Errorf("Min() of reversed map = %d; want 4", k)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("zero SortedMapFunc didn't panic")
+		}
+	}()
+	var z SortedMapFunc[int, int]
+	z.Store(1, 1)
+}
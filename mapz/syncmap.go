@@ -1,9 +1,20 @@
 package mapz
 
-import "sync"
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
 
+// SyncMap is a type safe wrapper around sync.Map.
+// The zero value is valid.
+//
+// SyncMap implements json.Marshaler with a pointer receiver, because sync.Map must not be copied. encoding/json only uses it if the SyncMap is addressable, so marshal a pointer to a struct containing a SyncMap. Marshaling such a struct by value encodes the SyncMap as {}.
 type SyncMap[K comparable, V any] struct {
 	m sync.Map
+
+	callsLock sync.Mutex
+	calls     map[K]*computeCall[V]
 }
 
 // Load returns the value stored in the map for a key. The ok result indicates whether value was found in the map.
@@ -55,6 +66,75 @@ func (m *SyncMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
 	return value, false
 }
 
+// LoadOrCompute returns the existing value for the key if present. Otherwise, it calls fn and stores and returns its result.
+// fn runs at most once per key at a time: concurrent callers for the same key wait for the running fn and get its result.
+// If fn returns an error, nothing is stored and the error is returned to all callers waiting for it. The next call will try again.
+// If fn panics, the panic is propagated to the caller that ran it and waiting callers get a *ComputePanicError.
+func (m *SyncMap[K, V]) LoadOrCompute(key K, fn func() (V, error)) (V, error) {
+	if v, ok := m.Load(key); ok {
+		return v, nil
+	}
+	return loadOrCompute(&m.callsLock, &m.calls, key, m.Load, m.computed, fn)
+}
+
+// LoadOrComputeContext is like LoadOrCompute, but callers waiting for the computation return ctx.Err() when their ctx is cancelled.
+// fn runs in its own goroutine, so it keeps going for the remaining callers when the caller that started it gives up.
+// The context passed to fn carries the values of the ctx of the caller that started it, and is only cancelled once all callers waiting for it have given up.
+// If fn panics, the caller that started it panics with a *ComputePanicError holding the panic value and stack trace, and the other callers waiting for it get that error.
+func (m *SyncMap[K, V]) LoadOrComputeContext(ctx context.Context, key K, fn func(ctx context.Context) (V, error)) (V, error) {
+	if v, ok := m.Load(key); ok {
+		return v, nil
+	}
+	return loadOrComputeContext(ctx, &m.callsLock, &m.calls, key, m.Load, m.computed, fn)
+}
+
+// computed stores the result of LoadOrCompute unless a value was stored in the meantime.
+func (m *SyncMap[K, V]) computed(key K, value V) V {
+	v, _ := m.LoadOrStore(key, value)
+	return v
+}
+
+// StoreAll sets the values for all keys in entries.
+// SyncMap doesn't have a single lock, so this is the same as calling Store for every entry and other goroutines might observe a partial result.
+func (m *SyncMap[K, V]) StoreAll(entries map[K]V) {
+	for k, v := range entries {
+		m.m.Store(k, v)
+	}
+}
+
+// LoadMany returns the values stored in the map for the given keys. Keys that aren't present are omitted from the result.
+// SyncMap doesn't have a single lock, so the result doesn't necessarily correspond to a consistent snapshot.
+func (m *SyncMap[K, V]) LoadMany(keys ...K) map[K]V {
+	ret := make(map[K]V, len(keys))
+	for _, k := range keys {
+		if v, ok := m.Load(k); ok {
+			ret[k] = v
+		}
+	}
+	return ret
+}
+
+// DeleteMany deletes the values for the given keys.
+// SyncMap doesn't have a single lock, so this is the same as calling Delete for every key.
+func (m *SyncMap[K, V]) DeleteMany(keys ...K) {
+	for _, k := range keys {
+		m.m.Delete(k)
+	}
+}
+
+// LoadAndDeleteAll deletes all values from the map and returns them.
+// SyncMap doesn't have a single lock, so values stored concurrently might or might not be deleted, but every deleted value is returned.
+func (m *SyncMap[K, V]) LoadAndDeleteAll() map[K]V {
+	ret := map[K]V{}
+	m.m.Range(func(k, _ any) bool {
+		if v, ok := m.LoadAndDelete(k.(K)); ok {
+			ret[k.(K)] = v
+		}
+		return true
+	})
+	return ret
+}
+
 // Range calls f sequentially for each key and value present in the map. If f returns false, range stops the iteration.
 //
 // Range does not necessarily correspond to any consistent snapshot of the Map's contents: no key will be visited more than once, but if the value for any key is stored or deleted concurrently (including by f), Range may reflect any mapping for that key from any point during the Range call. Range does not block other methods on the receiver; even f itself may call any method on m.
@@ -69,3 +149,33 @@ func (m *SyncMap[K, V]) Range(f func(key K, value V) bool) {
 		return f(k.(K), v.(V))
 	})
 }
+
+// MarshalJSON encodes the map as a JSON object, with the same rules as encoding/json uses for a map[K]V. Keys are sorted, integer keys by their value.
+// It has the same consistency guarantees as Range. The method has a pointer receiver, see SyncMap.
+func (m *SyncMap[K, V]) MarshalJSON() ([]byte, error) {
+	n := map[K]V{}
+	m.Range(func(k K, v V) bool {
+		n[k] = v
+		return true
+	})
+	return marshalSortedJSON(n)
+}
+
+// UnmarshalJSON replaces the contents of the map with the given JSON object.
+// This isn't atomic: concurrent readers can observe a mix of the old and new contents.
+func (m *SyncMap[K, V]) UnmarshalJSON(data []byte) error {
+	var n map[K]V
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	m.m.Range(func(k, _ any) bool {
+		if _, ok := n[k.(K)]; !ok {
+			m.m.Delete(k)
+		}
+		return true
+	})
+	for k, v := range n {
+		m.m.Store(k, v)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package mapz
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrDuplicateKey is returned by Invert and IndexBy when two entries end up with the same key.
+var ErrDuplicateKey = errors.New("mapz: duplicate key")
+
+// Pair is a key and its value.
+type Pair[K, V any] struct {
+	Key   K
+	Value V
+}
+
+// FilterMap returns a new map with the entries of m for which pred returns true.
+func FilterMap[M ~map[K]V, K comparable, V any](m M, pred func(key K, value V) bool) M {
+	ret := M{}
+	for k, v := range m {
+		if pred(k, v) {
+			ret[k] = v
+		}
+	}
+	return ret
+}
+
+// Partition splits m into a map with the entries for which pred returns true and one with the rest.
+func Partition[M ~map[K]V, K comparable, V any](m M, pred func(key K, value V) bool) (matched, rest M) {
+	matched, rest = M{}, M{}
+	for k, v := range m {
+		if pred(k, v) {
+			matched[k] = v
+		} else {
+			rest[k] = v
+		}
+	}
+	return matched, rest
+}
+
+// MapValues returns a new map with the same keys as m and fn applied to every value.
+func MapValues[M ~map[K]V, K comparable, V, W any](m M, fn func(value V) W) map[K]W {
+	ret := make(map[K]W, len(m))
+	for k, v := range m {
+		ret[k] = fn(v)
+	}
+	return ret
+}
+
+// MapKeys returns a new map with fn applied to every key of m.
+// If fn returns the same key for multiple entries, resolve is called with that key and the values to decide the value to keep. The order in which colliding values are passed is unspecified.
+// If resolve is nil, MapKeys panics on collisions.
+func MapKeys[M ~map[K]V, K, L comparable, V any](m M, fn func(key K) L, resolve func(key L, a, b V) V) map[L]V {
+	ret := make(map[L]V, len(m))
+	for k, v := range m {
+		l := fn(k)
+		if prev, ok := ret[l]; ok {
+			if resolve == nil {
+				panic(fmt.Sprintf("mapz.MapKeys: multiple keys map to %v", l))
+			}
+			v = resolve(l, prev, v)
+		}
+		ret[l] = v
+	}
+	return ret
+}
+
+// Merge returns a new map with the entries of all given maps.
+// If a key is present in multiple maps, resolve is called with the key, the value so far and the value from the later map to decide the value to keep. If resolve is nil, the value from the last map wins.
+func Merge[M ~map[K]V, K comparable, V any](resolve func(key K, a, b V) V, ms ...M) M {
+	size := 0
+	for _, m := range ms {
+		size += len(m)
+	}
+	ret := make(M, size)
+	for _, m := range ms {
+		for k, v := range m {
+			if prev, ok := ret[k]; ok && resolve != nil {
+				v = resolve(k, prev, v)
+			}
+			ret[k] = v
+		}
+	}
+	return ret
+}
+
+// Invert returns a new map from the values of m to their keys.
+// If multiple keys have the same value, it returns an error wrapping ErrDuplicateKey. See MultiMapInvert if you need to support that.
+func Invert[M ~map[K]V, K, V comparable](m M) (map[V]K, error) {
+	ret := make(map[V]K, len(m))
+	for k, v := range m {
+		if _, ok := ret[v]; ok {
+			return nil, fmt.Errorf("%w: %v", ErrDuplicateKey, v)
+		}
+		ret[v] = k
+	}
+	return ret, nil
+}
+
+// GroupBy groups the elements of s by the key returned by fn. The elements in a group are in the same order as in s.
+func GroupBy[S ~[]E, E any, K comparable](s S, fn func(elem E) K) map[K][]E {
+	ret := map[K][]E{}
+	for _, e := range s {
+		k := fn(e)
+		ret[k] = append(ret[k], e)
+	}
+	return ret
+}
+
+// CountBy counts the number of elements of s for every key returned by fn.
+func CountBy[S ~[]E, E any, K comparable](s S, fn func(elem E) K) map[K]int {
+	ret := map[K]int{}
+	for _, e := range s {
+		ret[fn(e)]++
+	}
+	return ret
+}
+
+// IndexBy returns a map from the key returned by fn to the element of s.
+// If fn returns the same key for multiple elements, it returns an error wrapping ErrDuplicateKey.
+func IndexBy[S ~[]E, E any, K comparable](s S, fn func(elem E) K) (map[K]E, error) {
+	ret := make(map[K]E, len(s))
+	for _, e := range s {
+		k := fn(e)
+		if _, ok := ret[k]; ok {
+			return nil, fmt.Errorf("%w: %v", ErrDuplicateKey, k)
+		}
+		ret[k] = e
+	}
+	return ret, nil
+}
+
+// ToPairs returns the entries of m as a slice of pairs, in unspecified order.
+func ToPairs[M ~map[K]V, K comparable, V any](m M) []Pair[K, V] {
+	ret := make([]Pair[K, V], 0, len(m))
+	for k, v := range m {
+		ret = append(ret, Pair[K, V]{k, v})
+	}
+	return ret
+}
+
+// FromPairs returns a map with the given pairs. If a key occurs more than once, the last pair wins.
+func FromPairs[K comparable, V any](pairs []Pair[K, V]) map[K]V {
+	ret := make(map[K]V, len(pairs))
+	for _, p := range pairs {
+		ret[p.Key] = p.Value
+	}
+	return ret
+}
@@ -0,0 +1,117 @@
+package mapz
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+type stringIntMap map[string]int
+
+func TestFilterMapAndPartition(t *testing.T) {
+	m := stringIntMap{"a": 1, "b": 2, "c": 3, "d": 4}
+	even := func(k string, v int) bool { return v%2 == 0 }
+	var got stringIntMap = FilterMap(m, even)
+	if want := (stringIntMap{"b": 2, "d": 4}); !reflect.DeepEqual(got, want) {
+		t.Errorf("FilterMap(even) = %v; want %v", got, want)
+	}
+	matched, rest := Partition(m, even)
+	if want := (stringIntMap{"a": 1, "c": 3}); !reflect.DeepEqual(matched, got) || !reflect.DeepEqual(rest, want) {
+		t.Errorf("Partition(even) = %v, %v; want %v, %v", matched, rest, got, want)
+	}
+	if got := FilterMap(stringIntMap(nil), even); got == nil || len(got) != 0 {
+		t.Errorf("FilterMap(nil) = %#v; want an empty map", got)
+	}
+}
+
+func TestMapValuesAndKeys(t *testing.T) {
+	m := map[string]int{"a": 1, "B": 2, "b": 3}
+	if got, want := MapValues(m, func(v int) bool { return v > 1 }), map[string]bool{"a": false, "B": true, "b": true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MapValues() = %v; want %v", got, want)
+	}
+	sum := func(key string, a, b int) int { return a + b }
+	if got, want := MapKeys(m, strings.ToLower, sum), map[string]int{"a": 1, "b": 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MapKeys(ToLower, sum) = %v; want %v", got, want)
+	}
+	if got, want := MapKeys(m, func(k string) string { return k + k }, nil), map[string]int{"aa": 1, "BB": 2, "bb": 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MapKeys(double, nil) = %v; want %v", got, want)
+	}
+}
+
+func TestMapKeysPanicsWithoutResolver(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("MapKeys with a collision and no resolver didn't panic")
+		}
+	}()
+	MapKeys(map[string]int{"a": 1, "A": 2}, strings.ToLower, nil)
+}
+
+func TestMerge(t *testing.T) {
+	a := stringIntMap{"x": 1, "y": 2}
+	b := stringIntMap{"y": 3, "z": 4}
+	c := stringIntMap{"y": 5}
+	if got, want := Merge(nil, a, b, c), (stringIntMap{"x": 1, "y": 5, "z": 4}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge(nil, a, b, c) = %v; want %v", got, want)
+	}
+	var calls []string
+	keepFirst := func(key string, a, b int) int {
+		calls = append(calls, key)
+		return a
+	}
+	if got, want := Merge(keepFirst, a, b, c), (stringIntMap{"x": 1, "y": 2, "z": 4}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge(keepFirst, a, b, c) = %v; want %v", got, want)
+	}
+	if want := []string{"y", "y"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("resolver was called for %v; want %v", calls, want)
+	}
+	if got := Merge[stringIntMap](nil); got == nil || len(got) != 0 {
+		t.Errorf("Merge() = %#v; want an empty map", got)
+	}
+}
+
+func TestInvert(t *testing.T) {
+	got, err := Invert(map[string]int{"a": 1, "b": 2})
+	if err != nil {
+		t.Fatalf("Invert failed: %v", err)
+	}
+	if want := map[int]string{1: "a", 2: "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Invert() = %v; want %v", got, want)
+	}
+	if _, err := Invert(map[string]int{"a": 1, "b": 1}); !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("Invert() with duplicate values = %v; want ErrDuplicateKey", err)
+	}
+}
+
+func TestGroupCountIndexBy(t *testing.T) {
+	words := []string{"apple", "avocado", "banana", "blueberry", "cherry", "apricot"}
+	first := func(s string) byte { return s[0] }
+	if got, want := GroupBy(words, first), map[byte][]string{'a': {"apple", "avocado", "apricot"}, 'b': {"banana", "blueberry"}, 'c': {"cherry"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GroupBy(first) = %v; want %v", got, want)
+	}
+	if got, want := CountBy(words, first), map[byte]int{'a': 3, 'b': 2, 'c': 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("CountBy(first) = %v; want %v", got, want)
+	}
+	if _, err := IndexBy(words, first); !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("IndexBy(first) = %v; want ErrDuplicateKey", err)
+	}
+	got, err := IndexBy(words[:4], func(s string) int { return len(s) })
+	if want := map[int]string{5: "apple", 7: "avocado", 6: "banana", 9: "blueberry"}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("IndexBy(len) = %v, %v; want %v", got, err, want)
+	}
+}
+
+func TestPairs(t *testing.T) {
+	m := map[string]int{"a": 1, "b": 2, "c": 3}
+	pairs := ToPairs(m)
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Key < pairs[j].Key })
+	if want := []Pair[string, int]{{"a", 1}, {"b", 2}, {"c", 3}}; !reflect.DeepEqual(pairs, want) {
+		t.Errorf("ToPairs() = %v; want %v", pairs, want)
+	}
+	pairs = append(pairs, Pair[string, int]{"a", 4})
+	if got, want := FromPairs(pairs), map[string]int{"a": 4, "b": 2, "c": 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FromPairs() = %v; want %v", got, want)
+	}
+}
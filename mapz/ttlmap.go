@@ -0,0 +1,228 @@
+package mapz
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// TTLMap is a map protected with a mutex whose entries expire after a per-entry time-to-live.
+// Expired entries are removed lazily when they are accessed, when Cleanup is called, or periodically by the janitor started with StartJanitor.
+// The zero value is valid. The exported fields should be set before the map is first used.
+type TTLMap[K comparable, V any] struct {
+	// Now returns the current time. If nil, time.Now is used. Tests can override it to control expiry without sleeping.
+	Now func() time.Time
+	// RefreshOnAccess makes Load, LoadOrZero and LoadOrStore extend the lifetime of the entry they return by its original TTL.
+	RefreshOnAccess bool
+	// OnEvict is called for every entry that is removed because it expired. It is not called for entries that are deleted or overwritten.
+	// OnEvict is called without holding the lock, so it may call any method on the map.
+	OnEvict func(key K, value V)
+
+	l sync.Mutex
+	m map[K]ttlEntry[V]
+}
+
+type ttlEntry[V any] struct {
+	value   V
+	ttl     time.Duration
+	expires time.Time
+}
+
+type ttlEviction[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+func (m *TTLMap[K, V]) now() time.Time {
+	if m.Now != nil {
+		return m.Now()
+	}
+	return time.Now()
+}
+
+func (m *TTLMap[K, V]) newEntry(value V, ttl time.Duration) ttlEntry[V] {
+	e := ttlEntry[V]{value: value}
+	if ttl > 0 {
+		e.ttl = ttl
+		e.expires = m.now().Add(ttl)
+	}
+	return e
+}
+
+func (e ttlEntry[V]) expired(now time.Time) bool {
+	return e.ttl > 0 && !now.Before(e.expires)
+}
+
+// loadLocked returns the entry for key if it hasn't expired. An expired entry is deleted and returned in evicted. m.l must be held.
+func (m *TTLMap[K, V]) loadLocked(key K) (v V, ok bool, evicted *ttlEviction[K, V]) {
+	e, ok := m.m[key]
+	if !ok {
+		return v, false, nil
+	}
+	now := m.now()
+	if e.expired(now) {
+		delete(m.m, key)
+		return v, false, &ttlEviction[K, V]{key, e.value}
+	}
+	if m.RefreshOnAccess && e.ttl > 0 {
+		e.expires = now.Add(e.ttl)
+		m.m[key] = e
+	}
+	return e.value, true, nil
+}
+
+func (m *TTLMap[K, V]) evicted(evictions ...ttlEviction[K, V]) {
+	if m.OnEvict == nil {
+		return
+	}
+	for _, e := range evictions {
+		m.OnEvict(e.key, e.value)
+	}
+}
+
+// Load returns the value stored in the map for a key. The ok result indicates whether an unexpired value was found in the map.
+func (m *TTLMap[K, V]) Load(key K) (V, bool) {
+	m.l.Lock()
+	v, ok, evicted := m.loadLocked(key)
+	m.l.Unlock()
+	if evicted != nil {
+		m.evicted(*evicted)
+	}
+	return v, ok
+}
+
+// LoadOrZero returns the value stored in the map for a key, or zero if no unexpired value is present. This is the same as Load() but ignoring the second result.
+func (m *TTLMap[K, V]) LoadOrZero(key K) V {
+	v, _ := m.Load(key)
+	return v
+}
+
+// Store sets the value for a key. The entry expires after ttl. A ttl of 0 or less means the entry never expires.
+func (m *TTLMap[K, V]) Store(key K, value V, ttl time.Duration) {
+	m.l.Lock()
+	defer m.l.Unlock()
+	if m.m == nil {
+		m.m = map[K]ttlEntry[V]{}
+	}
+	m.m[key] = m.newEntry(value, ttl)
+}
+
+// LoadOrStore returns the existing value for the key if present and unexpired. Otherwise, it stores the given value with the given ttl and returns it. The loaded result is true if the value was loaded, false if stored.
+func (m *TTLMap[K, V]) LoadOrStore(key K, value V, ttl time.Duration) (actual V, loaded bool) {
+	m.l.Lock()
+	v, ok, evicted := m.loadLocked(key)
+	if !ok {
+		if m.m == nil {
+			m.m = map[K]ttlEntry[V]{}
+		}
+		m.m[key] = m.newEntry(value, ttl)
+		v = value
+	}
+	m.l.Unlock()
+	if evicted != nil {
+		m.evicted(*evicted)
+	}
+	return v, ok
+}
+
+// Delete deletes the value for a key.
+func (m *TTLMap[K, V]) Delete(key K) {
+	m.l.Lock()
+	defer m.l.Unlock()
+	delete(m.m, key)
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if any. The second result reports whether an unexpired value was present.
+func (m *TTLMap[K, V]) LoadAndDelete(key K) (V, bool) {
+	m.l.Lock()
+	v, ok, evicted := m.loadLocked(key)
+	if ok {
+		delete(m.m, key)
+	}
+	m.l.Unlock()
+	if evicted != nil {
+		m.evicted(*evicted)
+	}
+	return v, ok
+}
+
+// Cleanup removes all expired entries from the map and calls OnEvict for each of them.
+func (m *TTLMap[K, V]) Cleanup() {
+	var evictions []ttlEviction[K, V]
+	m.l.Lock()
+	now := m.now()
+	for k, e := range m.m {
+		if e.expired(now) {
+			delete(m.m, k)
+			evictions = append(evictions, ttlEviction[K, V]{k, e.value})
+		}
+	}
+	m.l.Unlock()
+	m.evicted(evictions...)
+}
+
+// StartJanitor starts a goroutine that calls Cleanup every interval. The returned function stops the janitor and waits for it to exit.
+// The interval is measured in real time, even if Now is overridden. StartJanitor panics if interval isn't positive.
+func (m *TTLMap[K, V]) StartJanitor(interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		panic(fmt.Sprintf("mapz.TTLMap.StartJanitor: interval must be positive, got %v", interval))
+	}
+	quit := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				m.Cleanup()
+			case <-quit:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(quit)
+		})
+		<-done
+	}
+}
+
+// Range calls f sequentially for each key and unexpired value present in the map. If f returns false, range stops the iteration.
+//
+// Range does not block other methods on the receiver; even f itself may call any method on m.
+//
+// Range repeatedly picks up and drops the mutex so f() won't be called with the mutex held. Range doesn't remove expired entries or refresh the entries it visits.
+// Whether an entry has expired is judged against the time at which Range was called.
+func (m *TTLMap[K, V]) Range(f func(key K, value V) bool) {
+	m.l.Lock()
+	now := m.now()
+	for k, e := range m.m {
+		if e.expired(now) {
+			continue
+		}
+		m.l.Unlock()
+		if !f(k, e.value) {
+			return
+		}
+		m.l.Lock()
+	}
+	m.l.Unlock()
+}
+
+// Len returns the number of unexpired elements in the map.
+func (m *TTLMap[K, V]) Len() int {
+	m.l.Lock()
+	defer m.l.Unlock()
+	now := m.now()
+	n := 0
+	for _, e := range m.m {
+		if !e.expired(now) {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,153 @@
+package mapz
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestTTLMap(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	evicted := map[string]int{}
+	m := TTLMap[string, int]{
+		Now: clock.Now,
+		OnEvict: func(k string, v int) {
+			evicted[k] = v
+		},
+	}
+	m.Store("short", 1, time.Second)
+	m.Store("long", 2, time.Minute)
+	m.Store("forever", 3, 0)
+	if v, loaded := m.LoadOrStore("short", 4, time.Second); !loaded || v != 1 {
+		t.Errorf("LoadOrStore(short) = %d, %v; want 1, true", v, loaded)
+	}
+	if got := m.Len(); got != 3 {
+		t.Errorf("Len() = %d; want 3", got)
+	}
+
+	clock.Advance(time.Second)
+	if v, ok := m.Load("short"); ok {
+		t.Errorf("Load(short) = %d, true after expiry", v)
+	}
+	if evicted["short"] != 1 {
+		t.Errorf("OnEvict wasn't called for short: %v", evicted)
+	}
+	if got := m.Len(); got != 2 {
+		t.Errorf("Len() = %d; want 2", got)
+	}
+	if v, loaded := m.LoadOrStore("short", 5, time.Second); loaded || v != 5 {
+		t.Errorf("LoadOrStore(short) = %d, %v; want 5, false", v, loaded)
+	}
+
+	clock.Advance(time.Hour)
+	seen := map[string]int{}
+	m.Range(func(k string, v int) bool {
+		seen[k] = v
+		return true
+	})
+	if len(seen) != 1 || seen["forever"] != 3 {
+		t.Errorf("Range yielded %v; want only forever", seen)
+	}
+	m.Cleanup()
+	if evicted["long"] != 2 || evicted["short"] != 5 {
+		t.Errorf("Cleanup didn't evict everything: %v", evicted)
+	}
+	if v, ok := m.LoadAndDelete("forever"); !ok || v != 3 {
+		t.Errorf("LoadAndDelete(forever) = %d, %v; want 3, true", v, ok)
+	}
+	if _, ok := evicted["forever"]; ok {
+		t.Errorf("OnEvict was called for a deleted entry")
+	}
+}
+
+func TestTTLMapRefreshOnAccess(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	m := TTLMap[string, int]{
+		Now:             clock.Now,
+		RefreshOnAccess: true,
+	}
+	m.Store("a", 1, 10*time.Second)
+	for i := 0; i < 5; i++ {
+		clock.Advance(9 * time.Second)
+		if _, ok := m.Load("a"); !ok {
+			t.Fatalf("entry expired despite being accessed")
+		}
+	}
+	clock.Advance(10 * time.Second)
+	if _, ok := m.Load("a"); ok {
+		t.Errorf("entry didn't expire")
+	}
+}
+
+func TestTTLMapJanitor(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	evicted := make(chan string, 1)
+	m := TTLMap[string, int]{
+		Now: clock.Now,
+		OnEvict: func(k string, v int) {
+			evicted <- k
+		},
+	}
+	m.Store("a", 1, time.Second)
+	stop := m.StartJanitor(time.Millisecond)
+	defer stop()
+	clock.Advance(time.Second)
+	select {
+	case k := <-evicted:
+		if k != "a" {
+			t.Errorf("janitor evicted %q; want a", k)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("janitor didn't evict the expired entry")
+	}
+	stop()
+}
+
+func TestTTLMapJanitorInvalidInterval(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("StartJanitor(0) didn't panic")
+		}
+	}()
+	var m TTLMap[string, int]
+	m.StartJanitor(0)
+}
+
+func TestTTLMapRangeUsesOneTime(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	m := TTLMap[int, int]{
+		// Every call to Now advances the clock, so entries would start expiring halfway through Range if it called Now per entry.
+		Now: func() time.Time {
+			clock.Advance(time.Second)
+			return clock.Now()
+		},
+	}
+	for i := 0; i < 10; i++ {
+		m.Store(i, i, 15*time.Second)
+	}
+	n := 0
+	m.Range(func(k, v int) bool {
+		n++
+		return true
+	})
+	if n != 10 {
+		t.Errorf("Range visited %d entries; want 10", n)
+	}
+}
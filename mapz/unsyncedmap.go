@@ -1,5 +1,7 @@
 package mapz
 
+import "encoding/json"
+
 // UnsyncedMap is a map protected with a mutex. Its interface closely resembles sync.Map.
 // It's indended as a drop-in replacement for MutexMap and SyncMap when synchronization is no longer needed (but might become so in the future).
 // The zero value is valid.
@@ -117,6 +119,56 @@ func UnsyncedMapCompareAndSwap[K, V comparable](m *UnsyncedMap[K, V], key K, old
 	return false
 }
 
+// StoreAll sets the values for all keys in entries.
+func (m *UnsyncedMap[K, V]) StoreAll(entries map[K]V) {
+	if m.M == nil {
+		m.M = make(map[K]V, len(entries))
+	}
+	for k, v := range entries {
+		m.M[k] = v
+	}
+}
+
+// LoadMany returns the values stored in the map for the given keys. Keys that aren't present are omitted from the result.
+func (m *UnsyncedMap[K, V]) LoadMany(keys ...K) map[K]V {
+	ret := make(map[K]V, len(keys))
+	for _, k := range keys {
+		if v, ok := m.M[k]; ok {
+			ret[k] = v
+		}
+	}
+	return ret
+}
+
+// DeleteMany deletes the values for the given keys.
+func (m *UnsyncedMap[K, V]) DeleteMany(keys ...K) {
+	for _, k := range keys {
+		delete(m.M, k)
+	}
+}
+
+// LoadAndDeleteAll deletes all values from the map and returns them.
+func (m *UnsyncedMap[K, V]) LoadAndDeleteAll() map[K]V {
+	ret := m.M
+	m.M = nil
+	return ret
+}
+
+// MarshalJSON encodes the map as a JSON object, with the same rules as encoding/json uses for a map[K]V. Keys are sorted, integer keys by their value.
+func (m UnsyncedMap[K, V]) MarshalJSON() ([]byte, error) {
+	return marshalSortedJSON(m.M)
+}
+
+// UnmarshalJSON replaces the contents of the map with the given JSON object.
+func (m *UnsyncedMap[K, V]) UnmarshalJSON(data []byte) error {
+	var n map[K]V
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	m.M = n
+	return nil
+}
+
 // WithLock calls f. The name is based on the MutexMap method, but UnsyncedMap has no mutex.
 func (m *UnsyncedMap[K, V]) WithLock(f func(m map[K]V)) {
 	if m.M == nil {
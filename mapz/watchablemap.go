@@ -0,0 +1,354 @@
+package mapz
+
+import "sync"
+
+// WatchableMap is a map protected with a mutex that notifies subscribers of changes. Its interface closely resembles MutexMap.
+// Subscribers register with Watch, WatchKey, WatchChan or WatchKeyChan and receive an Event for every Store, Swap, Delete and other modification.
+//
+// Events are delivered by a goroutine per subscription, without holding the map's lock. Events for the same key are delivered in the order the modifications happened.
+// What happens when a subscriber can't keep up is configured with its BackpressurePolicy.
+//
+// The zero value is valid.
+type WatchableMap[K comparable, V any] struct {
+	l    sync.Mutex
+	m    map[K]V
+	subs []*Subscription[K, V]
+}
+
+// Event describes a modification of a WatchableMap.
+type Event[K comparable, V any] struct {
+	Key K
+	// Value is the new value. It is the zero value if the key was deleted.
+	Value V
+	// Deleted is true if the key was deleted.
+	Deleted bool
+	// Previous is the value before the modification, if any.
+	Previous V
+	// HadPrevious reports whether the key was present before the modification.
+	HadPrevious bool
+}
+
+// BackpressurePolicy decides what happens to events when a subscriber can't keep up.
+type BackpressurePolicy int
+
+const (
+	// Block makes writers wait until the subscriber has room in its buffer. Writers never wait while holding the map's lock, so they are slowed down but not deadlocked by a slow subscriber.
+	// A subscriber with the Block policy must not modify the map from its callback, as it would wait for itself.
+	Block BackpressurePolicy = iota
+	// Drop discards new events when the subscriber's buffer is full. Subscription.Dropped tells how many events were discarded.
+	Drop
+	// Coalesce merges a new event with the undelivered event for the same key, if any. The merged event has the new value and the Previous value of the older event.
+	// Events are never discarded, so the subscriber always gets to see the latest value of every key. The Buffer is ignored: the queue holds at most one event per key.
+	Coalesce
+)
+
+// WatchOptions configure a subscription.
+type WatchOptions struct {
+	// Policy decides what happens when the subscriber can't keep up.
+	Policy BackpressurePolicy
+	// Buffer is the number of undelivered events the subscription can hold before Policy kicks in. If 0, 64 is used. It is ignored for Coalesce.
+	Buffer int
+}
+
+// Subscription is a registered subscriber of a WatchableMap.
+type Subscription[K comparable, V any] struct {
+	m       *WatchableMap[K, V]
+	key     K
+	allKeys bool
+	policy  BackpressurePolicy
+	buffer  int
+	fn      func(Event[K, V])
+	quit    chan struct{}
+
+	mu      sync.Mutex
+	cond    sync.Cond
+	queue   []Event[K, V]
+	head    int
+	pending map[K]int
+	dropped uint64
+	closed  bool
+}
+
+// Watch calls fn for every modification of any key in the map. fn is called sequentially from a goroutine owned by the subscription, without holding the map's lock.
+func (m *WatchableMap[K, V]) Watch(opts WatchOptions, fn func(e Event[K, V])) *Subscription[K, V] {
+	var zero K
+	return m.newSubscription(zero, true, opts, fn).start()
+}
+
+// WatchKey calls fn for every modification of the given key. fn is called sequentially from a goroutine owned by the subscription, without holding the map's lock.
+func (m *WatchableMap[K, V]) WatchKey(key K, opts WatchOptions, fn func(e Event[K, V])) *Subscription[K, V] {
+	return m.newSubscription(key, false, opts, fn).start()
+}
+
+// WatchChan returns a channel that receives an Event for every modification of any key in the map. The channel is closed after Unsubscribe.
+func (m *WatchableMap[K, V]) WatchChan(opts WatchOptions) (<-chan Event[K, V], *Subscription[K, V]) {
+	var zero K
+	return m.subscribeChan(zero, true, opts)
+}
+
+// WatchKeyChan returns a channel that receives an Event for every modification of the given key. The channel is closed after Unsubscribe.
+func (m *WatchableMap[K, V]) WatchKeyChan(key K, opts WatchOptions) (<-chan Event[K, V], *Subscription[K, V]) {
+	return m.subscribeChan(key, false, opts)
+}
+
+func (m *WatchableMap[K, V]) subscribeChan(key K, allKeys bool, opts WatchOptions) (<-chan Event[K, V], *Subscription[K, V]) {
+	ch := make(chan Event[K, V])
+	// The callback needs s.quit, so create the subscription before it can receive events.
+	s := m.newSubscription(key, allKeys, opts, nil)
+	s.fn = func(e Event[K, V]) {
+		select {
+		case ch <- e:
+		case <-s.quit:
+		}
+	}
+	s.start()
+	go func() {
+		<-s.quit
+		s.mu.Lock()
+		for s.fn != nil {
+			// Wait for the delivery goroutine to exit before closing the channel.
+			s.cond.Wait()
+		}
+		s.mu.Unlock()
+		close(ch)
+	}()
+	return ch, s
+}
+
+// newSubscription creates a subscription. It doesn't receive events until start is called.
+func (m *WatchableMap[K, V]) newSubscription(key K, allKeys bool, opts WatchOptions, fn func(e Event[K, V])) *Subscription[K, V] {
+	s := &Subscription[K, V]{
+		m:       m,
+		key:     key,
+		allKeys: allKeys,
+		policy:  opts.Policy,
+		buffer:  opts.Buffer,
+		fn:      fn,
+		quit:    make(chan struct{}),
+	}
+	if s.buffer <= 0 {
+		s.buffer = 64
+	}
+	if s.policy == Coalesce {
+		s.pending = map[K]int{}
+	}
+	s.cond.L = &s.mu
+	return s
+}
+
+// start registers s with its map and starts delivering events.
+func (s *Subscription[K, V]) start() *Subscription[K, V] {
+	s.m.l.Lock()
+	s.m.subs = append(s.m.subs, s)
+	s.m.l.Unlock()
+	go s.deliver()
+	return s
+}
+
+// Unsubscribe stops the subscription. Undelivered events are discarded and writers blocked on this subscriber are released.
+// After Unsubscribe returns no new callbacks are started, but one might still be running. Unsubscribe may be called from the callback itself.
+func (s *Subscription[K, V]) Unsubscribe() {
+	s.m.l.Lock()
+	for i, o := range s.m.subs {
+		if o == s {
+			s.m.subs = append(s.m.subs[:i:i], s.m.subs[i+1:]...)
+			break
+		}
+	}
+	s.m.l.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	s.queue = nil
+	s.cond.Broadcast()
+	close(s.quit)
+}
+
+// Dropped returns the number of events that were discarded because the subscriber couldn't keep up.
+func (s *Subscription[K, V]) Dropped() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+func (s *Subscription[K, V]) deliver() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		for len(s.queue) == 0 && !s.closed {
+			s.cond.Wait()
+		}
+		if s.closed {
+			s.fn = nil
+			s.cond.Broadcast()
+			return
+		}
+		e := s.queue[0]
+		s.queue = s.queue[1:]
+		if s.pending != nil {
+			delete(s.pending, e.Key)
+		}
+		s.head++
+		s.cond.Broadcast()
+		fn := s.fn
+		s.mu.Unlock()
+		fn(e)
+		s.mu.Lock()
+	}
+}
+
+// enqueue adds e to the queue of s. The map's lock must be held, which guarantees the order of events.
+func (s *Subscription[K, V]) enqueue(e Event[K, V]) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	switch s.policy {
+	case Coalesce:
+		if i, ok := s.pending[e.Key]; ok {
+			old := &s.queue[i-s.head]
+			e.Previous, e.HadPrevious = old.Previous, old.HadPrevious
+			*old = e
+			return
+		}
+	case Drop:
+		if len(s.queue) >= s.buffer {
+			s.dropped++
+			return
+		}
+	}
+	if s.pending != nil {
+		s.pending[e.Key] = s.head + len(s.queue)
+	}
+	s.queue = append(s.queue, e)
+	s.cond.Broadcast()
+}
+
+// waitForRoom blocks until s has room in its buffer. It must be called without holding the map's lock.
+func (s *Subscription[K, V]) waitForRoom() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for len(s.queue) > s.buffer && !s.closed {
+		s.cond.Wait()
+	}
+}
+
+// notify queues e for all interested subscribers. m.l must be held. The returned subscriptions need waitForRoom to be called after m.l is released.
+func (m *WatchableMap[K, V]) notify(e Event[K, V]) []*Subscription[K, V] {
+	var blocking []*Subscription[K, V]
+	for _, s := range m.subs {
+		if !s.allKeys && s.key != e.Key {
+			continue
+		}
+		s.enqueue(e)
+		if s.policy == Block {
+			blocking = append(blocking, s)
+		}
+	}
+	return blocking
+}
+
+func waitForRoom[K comparable, V any](blocking []*Subscription[K, V]) {
+	for _, s := range blocking {
+		s.waitForRoom()
+	}
+}
+
+// Load returns the value stored in the map for a key. The ok result indicates whether value was found in the map.
+func (m *WatchableMap[K, V]) Load(key K) (V, bool) {
+	m.l.Lock()
+	defer m.l.Unlock()
+	v, ok := m.m[key]
+	return v, ok
+}
+
+// LoadOrZero returns the value stored in the map for a key, or zero if no value is present. This is the same as Load() but ignoring the second result.
+func (m *WatchableMap[K, V]) LoadOrZero(key K) V {
+	m.l.Lock()
+	defer m.l.Unlock()
+	return m.m[key]
+}
+
+// Store sets the value for a key.
+func (m *WatchableMap[K, V]) Store(key K, value V) {
+	m.Swap(key, value)
+}
+
+// Swap swaps the value for a key and returns the previous value if any. The loaded result reports whether the key was present.
+func (m *WatchableMap[K, V]) Swap(key K, value V) (previous V, loaded bool) {
+	m.l.Lock()
+	previous, loaded = m.m[key]
+	if m.m == nil {
+		m.m = map[K]V{}
+	}
+	m.m[key] = value
+	blocking := m.notify(Event[K, V]{Key: key, Value: value, Previous: previous, HadPrevious: loaded})
+	m.l.Unlock()
+	waitForRoom(blocking)
+	return previous, loaded
+}
+
+// Delete deletes the value for a key.
+func (m *WatchableMap[K, V]) Delete(key K) {
+	m.LoadAndDelete(key)
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if any. The second result reports whether the key was present.
+func (m *WatchableMap[K, V]) LoadAndDelete(key K) (V, bool) {
+	m.l.Lock()
+	v, ok := m.m[key]
+	if !ok {
+		m.l.Unlock()
+		return v, false
+	}
+	delete(m.m, key)
+	blocking := m.notify(Event[K, V]{Key: key, Deleted: true, Previous: v, HadPrevious: true})
+	m.l.Unlock()
+	waitForRoom(blocking)
+	return v, true
+}
+
+// LoadOrStore returns the existing value for the key if present. Otherwise, it stores and returns the given value. The loaded result is true if the value was loaded, false if stored.
+func (m *WatchableMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	m.l.Lock()
+	if v, ok := m.m[key]; ok {
+		m.l.Unlock()
+		return v, true
+	}
+	if m.m == nil {
+		m.m = map[K]V{}
+	}
+	m.m[key] = value
+	blocking := m.notify(Event[K, V]{Key: key, Value: value})
+	m.l.Unlock()
+	waitForRoom(blocking)
+	return value, false
+}
+
+// Range calls f sequentially for each key and value present in the map. If f returns false, range stops the iteration.
+//
+// Range does not block other methods on the receiver; even f itself may call any method on m.
+//
+// Range repeatedly picks up and drops the mutex so f() won't be called with the mutex held.
+func (m *WatchableMap[K, V]) Range(f func(key K, value V) bool) {
+	m.l.Lock()
+	for k, v := range m.m {
+		m.l.Unlock()
+		if !f(k, v) {
+			return
+		}
+		m.l.Lock()
+	}
+	m.l.Unlock()
+}
+
+// Len returns the number of elements in the map.
+func (m *WatchableMap[K, V]) Len() int {
+	m.l.Lock()
+	defer m.l.Unlock()
+	return len(m.m)
+}
@@ -0,0 +1,353 @@
+package mapz
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatchableMap(t *testing.T) {
+	var m WatchableMap[string, int]
+	ch, sub := m.WatchChan(WatchOptions{})
+	defer sub.Unsubscribe()
+
+	m.Store("a", 1)
+	m.Swap("a", 2)
+	m.LoadOrStore("a", 3)
+	m.LoadOrStore("b", 4)
+	m.Delete("a")
+	m.Delete("missing")
+
+	want := []Event[string, int]{
+		{Key: "a", Value: 1},
+		{Key: "a", Value: 2, Previous: 1, HadPrevious: true},
+		{Key: "b", Value: 4},
+		{Key: "a", Deleted: true, Previous: 2, HadPrevious: true},
+	}
+	for i, w := range want {
+		select {
+		case e := <-ch:
+			if e != w {
+				t.Errorf("event %d = %+v; want %+v", i, e, w)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for event %d", i)
+		}
+	}
+	if m.Len() != 1 || m.LoadOrZero("b") != 4 {
+		t.Errorf("map has unexpected contents")
+	}
+}
+
+func TestWatchableMapWatchKey(t *testing.T) {
+	var m WatchableMap[string, int]
+	var mtx sync.Mutex
+	var got []int
+	sub := m.WatchKey("b", WatchOptions{}, func(e Event[string, int]) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		got = append(got, e.Value)
+	})
+	m.Store("a", 1)
+	m.Store("b", 2)
+	m.Store("c", 3)
+	m.Store("b", 4)
+	sub.Unsubscribe()
+	m.Store("b", 5)
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	// Unsubscribe discards undelivered events, so we might have missed some.
+	for i, v := range got {
+		if want := []int{2, 4}[i]; v != want {
+			t.Errorf("got[%d] = %d; want %d", i, v, want)
+		}
+	}
+}
+
+func TestWatchableMapPerKeyOrdering(t *testing.T) {
+	for name, policy := range map[string]BackpressurePolicy{"Block": Block, "Coalesce": Coalesce} {
+		t.Run(name, func(t *testing.T) {
+			const writers = 8
+			const updates = 1000
+			var m WatchableMap[int, int]
+			var mtx sync.Mutex
+			last := map[int]int{}
+			done := make(chan struct{})
+			sub := m.Watch(WatchOptions{Policy: policy, Buffer: 4}, func(e Event[int, int]) {
+				mtx.Lock()
+				defer mtx.Unlock()
+				if e.Value <= last[e.Key] {
+					t.Errorf("key %d: got %d after %d", e.Key, e.Value, last[e.Key])
+				}
+				last[e.Key] = e.Value
+				if len(last) == writers {
+					complete := true
+					for _, v := range last {
+						if v != updates {
+							complete = false
+						}
+					}
+					if complete {
+						close(done)
+					}
+				}
+			})
+			defer sub.Unsubscribe()
+			var wg sync.WaitGroup
+			for w := 0; w < writers; w++ {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+					for i := 1; i <= updates; i++ {
+						m.Store(w, i)
+					}
+				}(w)
+			}
+			wg.Wait()
+			select {
+			case <-done:
+			case <-time.After(10 * time.Second):
+				t.Fatal("timed out waiting for the final values")
+			}
+			if policy == Block && sub.Dropped() != 0 {
+				t.Errorf("Dropped() = %d; want 0", sub.Dropped())
+			}
+		})
+	}
+}
+
+func TestWatchableMapSlowSubscriber(t *testing.T) {
+	for name, policy := range map[string]BackpressurePolicy{"Drop": Drop, "Coalesce": Coalesce} {
+		t.Run(name, func(t *testing.T) {
+			var m WatchableMap[int, int]
+			release := make(chan struct{})
+			sub := m.Watch(WatchOptions{Policy: policy, Buffer: 2}, func(e Event[int, int]) {
+				<-release
+			})
+			defer sub.Unsubscribe()
+			defer close(release)
+
+			finished := make(chan struct{})
+			go func() {
+				for i := 0; i < 1000; i++ {
+					m.Store(i%10, i)
+				}
+				close(finished)
+			}()
+			select {
+			case <-finished:
+			case <-time.After(5 * time.Second):
+				t.Fatal("writers were blocked by a stuck subscriber")
+			}
+			if policy == Drop && sub.Dropped() == 0 {
+				t.Errorf("Dropped() = 0; want events to be dropped")
+			}
+			if policy == Coalesce && sub.Dropped() != 0 {
+				t.Errorf("Dropped() = %d; want 0", sub.Dropped())
+			}
+		})
+	}
+}
+
+func TestWatchableMapCoalesce(t *testing.T) {
+	var m WatchableMap[string, int]
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	events := make(chan Event[string, int], 10)
+	sub := m.Watch(WatchOptions{Policy: Coalesce, Buffer: 2}, func(e Event[string, int]) {
+		if e.Key == "x" && !e.Deleted {
+			close(entered)
+			<-release
+		}
+		events <- e
+	})
+	defer sub.Unsubscribe()
+
+	// Wait until the first event is being delivered, so all following events queue up behind it.
+	m.Store("x", 0)
+	<-entered
+	for i := 1; i <= 100; i++ {
+		m.Store("a", i)
+	}
+	m.Delete("x")
+	close(release)
+
+	want := []Event[string, int]{
+		{Key: "x", Value: 0},
+		{Key: "a", Value: 100},
+		{Key: "x", Deleted: true, Previous: 0, HadPrevious: true},
+	}
+	for i, w := range want {
+		select {
+		case e := <-events:
+			if e != w {
+				t.Errorf("event %d = %+v; want %+v", i, e, w)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for event %d", i)
+		}
+	}
+	// All events were queued before the delete, so nothing can follow it.
+	m.Store("y", 1)
+	if e := <-events; e.Key != "y" {
+		t.Errorf("got unexpected event %+v", e)
+	}
+	if sub.Dropped() != 0 {
+		t.Errorf("Dropped() = %d; want 0", sub.Dropped())
+	}
+}
+
+func TestWatchableMapBlock(t *testing.T) {
+	const buffer = 1
+	var m WatchableMap[int, int]
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	var stored int64
+	var delivered sync.WaitGroup
+	delivered.Add(100)
+	sub := m.Watch(WatchOptions{Policy: Block, Buffer: buffer}, func(e Event[int, int]) {
+		defer delivered.Done()
+		if e.Key == 0 {
+			close(entered)
+			<-release
+		}
+		// Events 0 to e.Key have been taken from the queue, and the writer can only be ahead of that by the buffer.
+		if n := atomic.LoadInt64(&stored); n > int64(e.Key)+buffer+1 {
+			t.Errorf("%d Stores returned while event %d was being delivered; want at most %d", n, e.Key, e.Key+buffer+1)
+		}
+		// Readers can still use the map while a writer is blocked.
+		if _, ok := m.Load(e.Key); !ok {
+			t.Errorf("Load(%d) failed", e.Key)
+		}
+	})
+	defer sub.Unsubscribe()
+
+	finished := make(chan struct{})
+	go func() {
+		for i := 0; i < 100; i++ {
+			m.Store(i, i)
+			atomic.AddInt64(&stored, 1)
+		}
+		close(finished)
+	}()
+	<-entered
+	select {
+	case <-finished:
+		t.Fatal("writer wasn't blocked by a full subscriber")
+	default:
+	}
+	close(release)
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("writer wasn't released")
+	}
+	delivered.Wait()
+}
+
+func TestWatchableMapUnsubscribeReleasesWriters(t *testing.T) {
+	var m WatchableMap[int, int]
+	entered := make(chan struct{})
+	stuck := make(chan struct{})
+	defer close(stuck)
+	sub := m.Watch(WatchOptions{Policy: Block, Buffer: 1}, func(e Event[int, int]) {
+		if e.Key == 0 {
+			close(entered)
+		}
+		<-stuck
+	})
+	finished := make(chan struct{})
+	go func() {
+		for i := 0; i < 100; i++ {
+			m.Store(i, i)
+		}
+		close(finished)
+	}()
+	<-entered
+	select {
+	case <-finished:
+		t.Fatal("writer wasn't blocked by a stuck subscriber")
+	default:
+	}
+	sub.Unsubscribe()
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Unsubscribe didn't release the writer")
+	}
+}
+
+func TestWatchableMapChanClosed(t *testing.T) {
+	var m WatchableMap[int, int]
+	ch, sub := m.WatchKeyChan(1, WatchOptions{})
+	m.Store(1, 1)
+	m.Store(2, 2)
+	if e := <-ch; e.Key != 1 || e.Value != 1 {
+		t.Errorf("got %+v; want key 1 with value 1", e)
+	}
+	m.Store(1, 2)
+	sub.Unsubscribe()
+	sub.Unsubscribe()
+	for range ch {
+		// Drain events that were delivered before Unsubscribe.
+	}
+}
+
+func TestWatchableMapModifyFromCallback(t *testing.T) {
+	var m WatchableMap[int, int]
+	done := make(chan struct{})
+	var sub *Subscription[int, int]
+	sub = m.Watch(WatchOptions{Policy: Drop}, func(e Event[int, int]) {
+		if e.Value < 10 {
+			m.Store(e.Key, e.Value+1)
+			return
+		}
+		sub.Unsubscribe()
+		close(done)
+	})
+	m.Store(1, 0)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out")
+	}
+	if v := m.LoadOrZero(1); v != 10 {
+		t.Errorf("LoadOrZero(1) = %d; want 10", v)
+	}
+}
+
+func TestWatchableMapWatchChanDuringWrites(t *testing.T) {
+	var m WatchableMap[int, int]
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for w := 0; w < 2; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				m.Store(w, i)
+			}
+		}(w)
+	}
+	// Events can be delivered as soon as the subscription is registered, which is before WatchChan returns.
+	for i := 0; i < 5; i++ {
+		ch, sub := m.WatchChan(WatchOptions{Policy: Drop})
+		select {
+		case <-ch:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for an event")
+		}
+		sub.Unsubscribe()
+		for range ch {
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
@@ -0,0 +1,31 @@
+//go:build go1.23
+
+package setz
+
+import "iter"
+
+// Collect returns a new set with the elements yielded by seq.
+func Collect[T comparable](seq iter.Seq[T]) *Set[T] {
+	ret := &Set[T]{M: map[T]struct{}{}}
+	for e := range seq {
+		ret.M[e] = struct{}{}
+	}
+	return ret
+}
+
+// All returns an iterator over the elements of the set. It has the same guarantees as Range.
+func (s *Set[T]) All() iter.Seq[T] {
+	return s.Range
+}
+
+// AddSeq adds all elements yielded by seq to the set.
+func (s *Set[T]) AddSeq(seq iter.Seq[T]) {
+	for e := range seq {
+		s.Add(e)
+	}
+}
+
+// All returns an iterator over the elements of the set. It has the same guarantees as Range; in particular, the lock is not held while yielding.
+func (s *MutexSet[T]) All() iter.Seq[T] {
+	return s.Range
+}
@@ -0,0 +1,28 @@
+//go:build go1.23
+
+package setz
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestIterators(t *testing.T) {
+	s := Collect(slices.Values([]int{3, 1, 2, 1}))
+	if got := slices.Sorted(s.All()); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Errorf("All() = %v; want [1 2 3]", got)
+	}
+	s.AddSeq(maps.Keys(map[int]bool{4: true, 1: true}))
+	if got := Sorted(s); !slices.Equal(got, []int{1, 2, 3, 4}) {
+		t.Errorf("after AddSeq: %v; want [1 2 3 4]", got)
+	}
+	var m MutexSet[int]
+	m.AddAll(1, 2)
+	for e := range m.All() {
+		m.Remove(e)
+	}
+	if m.Len() != 0 {
+		t.Errorf("removing while iterating left %d elements", m.Len())
+	}
+}
@@ -0,0 +1,111 @@
+package setz
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// MutexSet is a Set protected with a mutex.
+// The zero value is an empty set.
+type MutexSet[T comparable] struct {
+	L sync.Mutex
+	S Set[T]
+}
+
+// Add adds e to the set. It returns false if e was already present.
+func (s *MutexSet[T]) Add(e T) bool {
+	s.L.Lock()
+	defer s.L.Unlock()
+	return s.S.Add(e)
+}
+
+// AddAll adds all given elements to the set.
+func (s *MutexSet[T]) AddAll(elems ...T) {
+	s.L.Lock()
+	defer s.L.Unlock()
+	s.S.AddAll(elems...)
+}
+
+// Remove removes e from the set. It returns whether e was present.
+func (s *MutexSet[T]) Remove(e T) bool {
+	s.L.Lock()
+	defer s.L.Unlock()
+	return s.S.Remove(e)
+}
+
+// RemoveAll removes all given elements from the set.
+func (s *MutexSet[T]) RemoveAll(elems ...T) {
+	s.L.Lock()
+	defer s.L.Unlock()
+	s.S.RemoveAll(elems...)
+}
+
+// Contains returns whether e is in the set.
+func (s *MutexSet[T]) Contains(e T) bool {
+	s.L.Lock()
+	defer s.L.Unlock()
+	return s.S.Contains(e)
+}
+
+// Len returns the number of elements in the set.
+func (s *MutexSet[T]) Len() int {
+	s.L.Lock()
+	defer s.L.Unlock()
+	return s.S.Len()
+}
+
+// Snapshot returns an unsynchronized copy of the set. Use it for set operations like Union and Intersection.
+func (s *MutexSet[T]) Snapshot() *Set[T] {
+	s.L.Lock()
+	defer s.L.Unlock()
+	return s.S.Clone()
+}
+
+// Slice returns the elements of the set in unspecified order.
+func (s *MutexSet[T]) Slice() []T {
+	s.L.Lock()
+	defer s.L.Unlock()
+	return s.S.Slice()
+}
+
+// WithLock calls f while holding the lock. f can manipulate the given set at will, but can't use s's regular functions because it's already holding the lock itself.
+func (s *MutexSet[T]) WithLock(f func(s *Set[T])) {
+	s.L.Lock()
+	defer s.L.Unlock()
+	f(&s.S)
+}
+
+// Range calls f sequentially for each element of the set. If f returns false, range stops the iteration.
+//
+// Range does not block other methods on the receiver; even f itself may call any method on s.
+//
+// Range repeatedly picks up and drops the mutex so f() won't be called with the mutex held. Use WithLock if you need more performance at the cost of blocking other users.
+func (s *MutexSet[T]) Range(f func(e T) bool) {
+	s.L.Lock()
+	for e := range s.S.M {
+		s.L.Unlock()
+		if !f(e) {
+			return
+		}
+		s.L.Lock()
+	}
+	s.L.Unlock()
+}
+
+// MarshalJSON encodes the set as a sorted JSON array, like Set.MarshalJSON.
+// The method has a pointer receiver, so marshal a pointer to any struct containing a MutexSet.
+func (s *MutexSet[T]) MarshalJSON() ([]byte, error) {
+	return s.Snapshot().MarshalJSON()
+}
+
+// UnmarshalJSON replaces the contents of the set with the elements of the given JSON array.
+func (s *MutexSet[T]) UnmarshalJSON(data []byte) error {
+	var n Set[T]
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	s.L.Lock()
+	defer s.L.Unlock()
+	s.S = n
+	return nil
+}
@@ -0,0 +1,266 @@
+// Package setz provides a generic set type.
+package setz
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"golang.org/x/exp/constraints"
+	"golang.org/x/exp/slices"
+)
+
+// Set is an unordered collection of unique elements. It isn't safe for concurrent use, see MutexSet for that.
+// M can be used directly, for example with the functions from mapz. Its values are always struct{}{}.
+// The zero value is an empty set.
+type Set[T comparable] struct {
+	M map[T]struct{}
+}
+
+// Of returns a new set with the given elements.
+func Of[T comparable](elems ...T) *Set[T] {
+	return FromSlice(elems)
+}
+
+// FromSlice returns a new set with the elements of s.
+func FromSlice[S ~[]T, T comparable](s S) *Set[T] {
+	ret := &Set[T]{M: make(map[T]struct{}, len(s))}
+	for _, e := range s {
+		ret.M[e] = struct{}{}
+	}
+	return ret
+}
+
+// FromMapKeys returns a new set with the keys of m.
+func FromMapKeys[M ~map[K]V, K comparable, V any](m M) *Set[K] {
+	ret := &Set[K]{M: make(map[K]struct{}, len(m))}
+	for k := range m {
+		ret.M[k] = struct{}{}
+	}
+	return ret
+}
+
+// Add adds e to the set. It returns false if e was already present.
+func (s *Set[T]) Add(e T) bool {
+	if _, ok := s.M[e]; ok {
+		return false
+	}
+	if s.M == nil {
+		s.M = map[T]struct{}{}
+	}
+	s.M[e] = struct{}{}
+	return true
+}
+
+// AddAll adds all given elements to the set.
+func (s *Set[T]) AddAll(elems ...T) {
+	if s.M == nil {
+		s.M = make(map[T]struct{}, len(elems))
+	}
+	for _, e := range elems {
+		s.M[e] = struct{}{}
+	}
+}
+
+// Remove removes e from the set. It returns whether e was present.
+func (s *Set[T]) Remove(e T) bool {
+	if _, ok := s.M[e]; !ok {
+		return false
+	}
+	delete(s.M, e)
+	return true
+}
+
+// RemoveAll removes all given elements from the set.
+func (s *Set[T]) RemoveAll(elems ...T) {
+	for _, e := range elems {
+		delete(s.M, e)
+	}
+}
+
+// Contains returns whether e is in the set.
+func (s *Set[T]) Contains(e T) bool {
+	_, ok := s.M[e]
+	return ok
+}
+
+// Len returns the number of elements in the set.
+func (s *Set[T]) Len() int {
+	return len(s.M)
+}
+
+// Clone returns a copy of the set.
+func (s *Set[T]) Clone() *Set[T] {
+	ret := &Set[T]{M: make(map[T]struct{}, len(s.M))}
+	for e := range s.M {
+		ret.M[e] = struct{}{}
+	}
+	return ret
+}
+
+// Union returns a new set with the elements that are in s, o or both.
+func (s *Set[T]) Union(o *Set[T]) *Set[T] {
+	ret := s.Clone()
+	for e := range o.M {
+		ret.M[e] = struct{}{}
+	}
+	return ret
+}
+
+// Intersection returns a new set with the elements that are in both s and o.
+func (s *Set[T]) Intersection(o *Set[T]) *Set[T] {
+	small, large := s, o
+	if small.Len() > large.Len() {
+		small, large = large, small
+	}
+	ret := &Set[T]{M: map[T]struct{}{}}
+	for e := range small.M {
+		if large.Contains(e) {
+			ret.M[e] = struct{}{}
+		}
+	}
+	return ret
+}
+
+// Difference returns a new set with the elements of s that aren't in o.
+func (s *Set[T]) Difference(o *Set[T]) *Set[T] {
+	ret := &Set[T]{M: map[T]struct{}{}}
+	for e := range s.M {
+		if !o.Contains(e) {
+			ret.M[e] = struct{}{}
+		}
+	}
+	return ret
+}
+
+// SymmetricDifference returns a new set with the elements that are in either s or o, but not in both.
+func (s *Set[T]) SymmetricDifference(o *Set[T]) *Set[T] {
+	ret := s.Difference(o)
+	for e := range o.M {
+		if !s.Contains(e) {
+			ret.M[e] = struct{}{}
+		}
+	}
+	return ret
+}
+
+// IsSubset returns whether every element of s is also in o.
+func (s *Set[T]) IsSubset(o *Set[T]) bool {
+	if s.Len() > o.Len() {
+		return false
+	}
+	for e := range s.M {
+		if !o.Contains(e) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsSuperset returns whether every element of o is also in s.
+func (s *Set[T]) IsSuperset(o *Set[T]) bool {
+	return o.IsSubset(s)
+}
+
+// Equal returns whether s and o contain the same elements.
+func (s *Set[T]) Equal(o *Set[T]) bool {
+	return s.Len() == o.Len() && s.IsSubset(o)
+}
+
+// Slice returns the elements of the set in unspecified order.
+func (s *Set[T]) Slice() []T {
+	ret := make([]T, 0, len(s.M))
+	for e := range s.M {
+		ret = append(ret, e)
+	}
+	return ret
+}
+
+// Range calls f sequentially for each element of the set. If f returns false, range stops the iteration.
+func (s *Set[T]) Range(f func(e T) bool) {
+	for e := range s.M {
+		if !f(e) {
+			return
+		}
+	}
+}
+
+// Sorted returns the elements of the set in ascending order. NaNs are ordered first, like slices.Sort does.
+//
+// This is a function rather than a method because Go 1.18 doesn't allow restricting a method's type parameters more than the base type (yet?).
+func Sorted[T constraints.Ordered](s *Set[T]) []T {
+	ret := s.Slice()
+	slices.SortFunc(ret, lessOrdered[T])
+	return ret
+}
+
+// lessOrdered orders NaNs before other values, like slices.Sort.
+func lessOrdered[T constraints.Ordered](a, b T) bool {
+	return a < b || (a != a && b == b)
+}
+
+// SortedFunc returns the elements of the set in the order defined by cmp. cmp should return a negative number if a < b, a positive number if a > b and 0 if they are equal, like cmp.Compare.
+func SortedFunc[T comparable](s *Set[T], cmp func(a, b T) int) []T {
+	ret := s.Slice()
+	slices.SortFunc(ret, func(a, b T) bool {
+		return cmp(a, b) < 0
+	})
+	return ret
+}
+
+// MarshalJSON encodes the set as a JSON array. The elements are sorted to get stable output: numbers and strings by their value, and other types by their JSON encoding.
+// The method has a pointer receiver, so marshal a pointer to any struct containing a Set.
+func (s *Set[T]) MarshalJSON() ([]byte, error) {
+	elems := s.Slice()
+	if !sortByValue(elems) {
+		encoded := make([]json.RawMessage, len(elems))
+		for i, e := range elems {
+			b, err := json.Marshal(e)
+			if err != nil {
+				return nil, err
+			}
+			encoded[i] = b
+		}
+		sort.Slice(encoded, func(i, j int) bool {
+			return string(encoded[i]) < string(encoded[j])
+		})
+		return json.Marshal(encoded)
+	}
+	return json.Marshal(elems)
+}
+
+// UnmarshalJSON replaces the contents of the set with the elements of the given JSON array. Duplicates are ignored.
+func (s *Set[T]) UnmarshalJSON(data []byte) error {
+	var elems []T
+	if err := json.Unmarshal(data, &elems); err != nil {
+		return err
+	}
+	s.M = FromSlice(elems).M
+	return nil
+}
+
+// sortByValue sorts elems if they are numbers or strings (that don't have their own MarshalJSON) and returns whether it did.
+func sortByValue[T any](elems []T) bool {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	marshaler := reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	if t.Implements(marshaler) || reflect.PointerTo(t).Implements(marshaler) {
+		return false
+	}
+	var less func(a, b reflect.Value) bool
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		less = func(a, b reflect.Value) bool { return a.Int() < b.Int() }
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		less = func(a, b reflect.Value) bool { return a.Uint() < b.Uint() }
+	case reflect.Float32, reflect.Float64:
+		less = func(a, b reflect.Value) bool { return lessOrdered(a.Float(), b.Float()) }
+	case reflect.String:
+		less = func(a, b reflect.Value) bool { return a.String() < b.String() }
+	default:
+		return false
+	}
+	sort.Slice(elems, func(i, j int) bool {
+		return less(reflect.ValueOf(elems[i]), reflect.ValueOf(elems[j]))
+	})
+	return true
+}
@@ -0,0 +1,161 @@
+package setz
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+	var s Set[int]
+	if s.Contains(1) || s.Len() != 0 || s.Remove(1) {
+		t.Errorf("zero Set isn't empty")
+	}
+	if !s.Add(1) || s.Add(1) {
+		t.Errorf("Add didn't report correctly whether the element was new")
+	}
+	s.AddAll(2, 3, 3)
+	if s.Len() != 3 || !s.Contains(3) {
+		t.Errorf("Set has %d elements, want 3", s.Len())
+	}
+	if !s.Remove(2) || s.Remove(2) || s.Contains(2) {
+		t.Errorf("Remove didn't report correctly whether the element was present")
+	}
+	s.RemoveAll(1, 4)
+	if got := s.Slice(); !reflect.DeepEqual(got, []int{3}) {
+		t.Errorf("Slice() = %v; want [3]", got)
+	}
+}
+
+func TestSetOperations(t *testing.T) {
+	a := Of(1, 2, 3, 4)
+	b := FromSlice([]int{3, 4, 5})
+	for name, tc := range map[string]struct {
+		got  *Set[int]
+		want []int
+	}{
+		"Union":               {a.Union(b), []int{1, 2, 3, 4, 5}},
+		"Intersection":        {a.Intersection(b), []int{3, 4}},
+		"Difference":          {a.Difference(b), []int{1, 2}},
+		"SymmetricDifference": {a.SymmetricDifference(b), []int{1, 2, 5}},
+		"Intersection/empty":  {a.Intersection(&Set[int]{}), []int{}},
+	} {
+		if got := Sorted(tc.got); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s() = %v; want %v", name, got, tc.want)
+		}
+	}
+	if a.Len() != 4 || b.Len() != 3 {
+		t.Errorf("set operations modified their operands")
+	}
+	if !Of(3, 4).IsSubset(a) || a.IsSubset(b) || !a.IsSuperset(Of(1)) || !(&Set[int]{}).IsSubset(b) {
+		t.Errorf("IsSubset or IsSuperset returned the wrong result")
+	}
+	if !a.Equal(Of(4, 3, 2, 1)) || a.Equal(b) || a.Equal(Of(1, 2, 3, 5)) {
+		t.Errorf("Equal returned the wrong result")
+	}
+	c := a.Clone()
+	c.Add(9)
+	if a.Contains(9) {
+		t.Errorf("modifying a Clone modified the original")
+	}
+}
+
+func TestConversions(t *testing.T) {
+	s := FromMapKeys(map[string]int{"b": 1, "a": 2})
+	if got, want := Sorted(s), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Sorted(FromMapKeys()) = %v; want %v", got, want)
+	}
+	byLen := func(a, b string) int { return len(a) - len(b) }
+	if got, want := SortedFunc(Of("ccc", "a", "bb"), byLen), []string{"a", "bb", "ccc"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SortedFunc(byLen) = %v; want %v", got, want)
+	}
+	if got := Sorted(Of(2, math.NaN(), 1)); !math.IsNaN(got[0]) || got[1] != 1 || got[2] != 2 {
+		t.Errorf("Sorted() = %v; want [NaN 1 2]", got)
+	}
+}
+
+type point struct {
+	X, Y int
+}
+
+func TestSetJSON(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		set  json.Marshaler
+		want string
+	}{
+		{"ints", Of(10, 2, -1), `[-1,2,10]`},
+		{"strings", Of("b", "a", "c"), `["a","b","c"]`},
+		{"structs", Of(point{2, 1}, point{1, 2}), `[{"X":1,"Y":2},{"X":2,"Y":1}]`},
+		{"empty", &Set[int]{}, `[]`},
+		{"MutexSet", func() json.Marshaler { var s MutexSet[uint]; s.AddAll(5, 3); return &s }(), `[3,5]`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := json.Marshal(tc.set)
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+			if string(b) != tc.want {
+				t.Errorf("Marshal() = %s; want %s", b, tc.want)
+			}
+		})
+	}
+
+	var s struct {
+		S Set[int]
+		M MutexSet[string]
+	}
+	if err := json.Unmarshal([]byte(`{"S": [3, 1, 3], "M": ["x"]}`), &s); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if !s.S.Equal(Of(1, 3)) || !s.M.Contains("x") || s.M.Len() != 1 {
+		t.Errorf("Unmarshal() = %v, %v; want [1 3], [x]", Sorted(&s.S), s.M.Slice())
+	}
+	if err := json.Unmarshal([]byte(`{"a": 1}`), &s.S); err == nil {
+		t.Errorf("Unmarshal of an object succeeded")
+	}
+
+	// JSON can't encode NaN, but MarshalJSON sorts before it finds out.
+	floats := []float64{2, math.NaN(), 1, math.NaN(), math.Inf(-1)}
+	if !sortByValue(floats) || !math.IsNaN(floats[0]) || !math.IsNaN(floats[1]) || !sort.Float64sAreSorted(floats[2:]) {
+		t.Errorf("sortByValue() = %v; want [NaN NaN -Inf 1 2]", floats)
+	}
+}
+
+func TestMutexSet(t *testing.T) {
+	var s MutexSet[string]
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				e := strconv.Itoa(i % 100)
+				s.Add(e)
+				s.Range(func(e string) bool {
+					s.Contains(e)
+					return true
+				})
+				if i%2 == 1 {
+					s.Remove(e)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	got := s.Slice()
+	sort.Strings(got)
+	if snap := s.Snapshot(); snap.Len() != len(got) {
+		t.Errorf("Snapshot() has %d elements; Slice() %d", snap.Len(), len(got))
+	}
+	s.WithLock(func(u *Set[string]) {
+		u.RemoveAll(got...)
+	})
+	if s.Len() != 0 {
+		t.Errorf("Len() = %d after removing everything", s.Len())
+	}
+}
@@ -1,5 +1,7 @@
 package slicez
 
+import "github.com/Jille/genericz/setz"
+
 // MustIndex is like [slices.Index], but panics instead of returning -1.
 func MustIndex[S ~[]E, E comparable](s S, v E) int {
 	for i := range s {
@@ -12,13 +14,10 @@ func MustIndex[S ~[]E, E comparable](s S, v E) int {
 
 // Diff returns `a` with all elements occurring in `b` removed.
 func Diff[T comparable](a, b []T) []T {
-	drop := make(map[T]struct{}, len(b))
-	for _, e := range b {
-		drop[e] = struct{}{}
-	}
+	drop := setz.FromSlice(b)
 	var out []T
 	for _, e := range a {
-		if _, ok := drop[e]; !ok {
+		if !drop.Contains(e) {
 			out = append(out, e)
 		}
 	}
@@ -60,11 +59,10 @@ outer:
 
 func unique_map[T comparable](a []T) []T {
 	var out []T
-	seen := make(map[T]struct{}, len(a))
+	seen := setz.Set[T]{M: make(map[T]struct{}, len(a))}
 	for _, e := range a {
-		if _, s := seen[e]; !s {
+		if seen.Add(e) {
 			out = append(out, e)
-			seen[e] = struct{}{}
 		}
 	}
 	return out
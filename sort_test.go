@@ -0,0 +1,185 @@
+package genericz
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strconv"
+	"testing"
+
+	"golang.org/x/exp/constraints"
+)
+
+func TestSortWithData(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	inputs := map[string]func(i int) int{
+		"random":   func(i int) int { return rnd.Intn(1000) },
+		"few":      func(i int) int { return rnd.Intn(3) },
+		"sorted":   func(i int) int { return i },
+		"reversed": func(i int) int { return -i },
+		"equal":    func(i int) int { return 7 },
+		"sawtooth": func(i int) int { return i % 17 },
+	}
+	for name, gen := range inputs {
+		for _, n := range []int{0, 1, 2, 5, 13, 20, 21, 100, 1000, 10000} {
+			keys := make([]int, n)
+			for i := range keys {
+				keys[i] = gen(i)
+			}
+			// want is keys sorted stably, with the original index as data.
+			want := make([]int, n)
+			for i := range want {
+				want[i] = i
+			}
+			sort.SliceStable(want, func(i, j int) bool {
+				return keys[want[i]] < keys[want[j]]
+			})
+			check := func(t *testing.T, c, d []int, stable bool) {
+				t.Helper()
+				for i := range c {
+					if c[i] != keys[d[i]] {
+						t.Fatalf("element %d has key %d but data %d, which belongs to key %d", i, c[i], d[i], keys[d[i]])
+					}
+					if c[i] != keys[want[i]] {
+						t.Fatalf("element %d has key %d; want %d", i, c[i], keys[want[i]])
+					}
+				}
+				if stable && !reflect.DeepEqual(d, want) {
+					t.Fatalf("not stable: got data %v; want %v", d, want)
+				}
+			}
+			run := func(sortFunc func(c, d []int), stable bool) func(t *testing.T) {
+				return func(t *testing.T) {
+					c := append([]int(nil), keys...)
+					d := make([]int, n)
+					for i := range d {
+						d[i] = i
+					}
+					sortFunc(c, d)
+					check(t, c, d, stable)
+				}
+			}
+			cmpInt := func(a, b int) int { return a - b }
+			prefix := name + "/" + strconv.Itoa(n)
+			t.Run(prefix+"/SortWithData", run(SortWithData[int, int], false))
+			t.Run(prefix+"/SortWithDataStable", run(SortWithDataStable[int, int], true))
+			t.Run(prefix+"/SortWithDataFunc", run(func(c, d []int) { SortWithDataFunc(c, d, cmpInt) }, false))
+			t.Run(prefix+"/SortWithDataStableFunc", run(func(c, d []int) { SortWithDataStableFunc(c, d, cmpInt) }, true))
+			t.Run(prefix+"/SortWithDataSlices", run(func(c, d []int) { SortWithDataSlices(c, d) }, false))
+		}
+	}
+}
+
+func TestSortWithDataNaN(t *testing.T) {
+	nan := math.NaN()
+	c := []float64{3, nan, 1, math.Inf(-1), nan, 2}
+	d := []string{"3", "nan1", "1", "-inf", "nan2", "2"}
+	SortWithDataStable(c, d)
+	if want := []string{"nan1", "nan2", "-inf", "1", "2", "3"}; !reflect.DeepEqual(d, want) {
+		t.Errorf("SortWithDataStable() ordered the data as %v; want %v", d, want)
+	}
+	c = make([]float64, 1000)
+	for i := range c {
+		c[i] = float64(i % 10)
+		if i%10 == 5 {
+			c[i] = nan
+		}
+	}
+	SortWithData(c, make([]int, len(c)))
+	for i := range c {
+		if math.IsNaN(c[i]) != (i < 100) {
+			t.Fatalf("SortWithData() put %v at index %d", c[i], i)
+		}
+		if i > 100 && c[i] < c[i-1] {
+			t.Fatalf("SortWithData() put %v after %v", c[i], c[i-1])
+		}
+	}
+}
+
+func TestSortWithDataSlices(t *testing.T) {
+	c := []int{3, 1, 2}
+	a := []string{"c", "a", "b"}
+	b := []bool{false, true, false}
+	SortWithDataSlices(c, a, b)
+	if !reflect.DeepEqual(a, []string{"a", "b", "c"}) || !reflect.DeepEqual(b, []bool{true, false, false}) {
+		t.Errorf("SortWithDataSlices() reordered the data as %v and %v", a, b)
+	}
+}
+
+func TestSortWithDataPanics(t *testing.T) {
+	for name, f := range map[string]func(){
+		"SortWithData":                   func() { SortWithData([]int{1, 2}, []int{1}) },
+		"SortWithDataStableFunc":         func() { SortWithDataStableFunc([]int{1}, []int{1, 2}, func(a, b int) int { return a - b }) },
+		"SortWithDataSlices":             func() { SortWithDataSlices([]int{1, 2}, []int{1, 2}, []string{"a"}) },
+		"SortWithDataSlices/not a slice": func() { SortWithDataSlices([]int{1, 2}, "ab") },
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("%s didn't panic", name)
+				}
+			}()
+			f()
+		})
+	}
+}
+
+// sortInterface is how SortWithData used to be implemented: a sort.Interface for sort.Sort and sort.Stable. The benchmarks compare against it.
+type sortInterface[C constraints.Ordered, D any] struct {
+	comparables []C
+	data        []D
+}
+
+func (s sortInterface[C, D]) Len() int {
+	return len(s.comparables)
+}
+
+func (s sortInterface[C, D]) Less(i, j int) bool {
+	return lessOrdered(s.comparables[i], s.comparables[j])
+}
+
+func (s sortInterface[C, D]) Swap(i, j int) {
+	s.comparables[i], s.comparables[j] = s.comparables[j], s.comparables[i]
+	s.data[i], s.data[j] = s.data[j], s.data[i]
+}
+
+func benchmarkSort(b *testing.B, n int, sortFunc func(keys []float64, data []string)) {
+	rnd := rand.New(rand.NewSource(1))
+	orig := make([]float64, n)
+	for i := range orig {
+		orig[i] = rnd.Float64()
+	}
+	keys := make([]float64, n)
+	data := make([]string, n)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		copy(keys, orig)
+		b.StartTimer()
+		sortFunc(keys, data)
+	}
+}
+
+func BenchmarkSortWithData(b *testing.B) {
+	for _, n := range []int{100, 10000} {
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			b.Run("SortWithData", func(b *testing.B) {
+				benchmarkSort(b, n, SortWithData[float64, string])
+			})
+			b.Run("sort.Sort", func(b *testing.B) {
+				benchmarkSort(b, n, func(keys []float64, data []string) {
+					sort.Sort(sortInterface[float64, string]{keys, data})
+				})
+			})
+			b.Run("SortWithDataStable", func(b *testing.B) {
+				benchmarkSort(b, n, SortWithDataStable[float64, string])
+			})
+			b.Run("sort.Stable", func(b *testing.B) {
+				benchmarkSort(b, n, func(keys []float64, data []string) {
+					sort.Stable(sortInterface[float64, string]{keys, data})
+				})
+			})
+		})
+	}
+}